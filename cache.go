@@ -1,7 +1,9 @@
 package cache
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"strconv"
 
 	redis "github.com/redis/go-redis/v9"
@@ -11,7 +13,8 @@ import (
 // Cache represents the interface for interacting with a cache.
 type Cache interface {
 	// Get retrieves the value associated with the given key from the cache.
-	// It returns the value as a string and an error if the key is not found or an error occurs.
+	// It returns the value as a string and an error if an error occurs.
+	// If the key is not found or has expired, it returns an empty string and driver.ErrMiss.
 	Get(key string) (string, error)
 
 	// Set sets the value associated with the given key in the cache.
@@ -20,6 +23,7 @@ type Cache interface {
 
 	// SetWithExpire sets the value associated with the given key in the cache with a specified time-to-live (TTL).
 	// The value will expire and be automatically deleted from the cache after the specified TTL (in seconds).
+	// A TTL of zero stores the value without expiration.
 	// It returns an error if an error occurs while setting the value.
 	SetWithExpire(key string, value string, ttl uint64) error
 
@@ -43,15 +47,173 @@ type Cache interface {
 	GetDriverName() string
 }
 
+// Scanner is implemented by caches that can enumerate the keys they hold.
+// Both built-in drivers implement it; use a type assertion to access it from a Cache.
+type Scanner interface {
+	// Scan calls fn for every key matching the glob-style pattern, using the Redis SCAN MATCH rules.
+	// An empty pattern matches every key. Iteration stops when fn returns false or the context is done.
+	Scan(ctx context.Context, pattern string, fn func(key string) bool) error
+
+	// Len returns the number of entries currently stored in the cache.
+	Len(ctx context.Context) (int64, error)
+}
+
+// PatternDeleter is implemented by caches that can remove many keys at once.
+// Both built-in drivers implement it; use a type assertion to access it from a Cache.
+type PatternDeleter interface {
+	// DeleteByPattern removes every key matching the glob-style pattern and returns how many were removed.
+	DeleteByPattern(ctx context.Context, pattern string) (int64, error)
+
+	// DeleteByPrefix removes every key starting with the literal prefix and returns how many were removed.
+	DeleteByPrefix(ctx context.Context, prefix string) (int64, error)
+}
+
+// Tagger is implemented by caches that can group entries under tags and invalidate them together.
+// Both built-in drivers implement it; use a type assertion to access it from a Cache.
+type Tagger interface {
+	// SetWithTags sets the value associated with the given key and attaches the given tags to it.
+	// A TTL (in seconds) of zero stores the value without expiration.
+	SetWithTags(key string, value string, ttl uint64, tags ...string) error
+
+	// InvalidateTags deletes every value carrying at least one of the given tags and returns how many were removed.
+	InvalidateTags(tags ...string) (int64, error)
+
+	// KeysByTag returns the keys of the values currently carrying the given tag.
+	KeysByTag(tag string) ([]string, error)
+}
+
+// HashCache is implemented by caches that can store structured records as hashes of fields.
+// Both built-in drivers implement it; use a type assertion to access it from a Cache.
+type HashCache interface {
+	// HGet retrieves the value of a field of the hash stored under the given key.
+	// If the key or the field does not exist, it returns an empty string and driver.ErrMiss.
+	HGet(key string, field string) (string, error)
+
+	// HGetAll retrieves every field of the hash stored under the given key.
+	HGetAll(key string) (map[string]string, error)
+
+	// HSet sets the given fields of the hash stored under the given key, keeping its TTL.
+	HSet(key string, fields map[string]string) error
+
+	// HSetWithExpire sets the given fields of the hash stored under the given key
+	// and sets the TTL (in seconds) of the whole hash. A TTL of zero removes the expiration of the hash.
+	HSetWithExpire(key string, fields map[string]string, ttl uint64) error
+
+	// HDel removes the given fields from the hash stored under the given key.
+	HDel(key string, fields ...string) error
+}
+
+// ListCache is implemented by caches that can store lists of values.
+// Both built-in drivers implement it; use a type assertion to access it from a Cache.
+type ListCache interface {
+	// LPush inserts the values at the head of the list and returns its new length.
+	LPush(key string, values ...string) (int64, error)
+
+	// RPush appends the values at the tail of the list and returns its new length.
+	RPush(key string, values ...string) (int64, error)
+
+	// LPop removes and returns the first element of the list.
+	// If the list does not exist, it returns an empty string and driver.ErrMiss.
+	LPop(key string) (string, error)
+
+	// RPop removes and returns the last element of the list.
+	// If the list does not exist, it returns an empty string and driver.ErrMiss.
+	RPop(key string) (string, error)
+
+	// LRange returns the elements between the start and stop indexes, inclusive. Negative indexes count from the tail.
+	LRange(key string, start int64, stop int64) ([]string, error)
+
+	// LLen returns the length of the list.
+	LLen(key string) (int64, error)
+}
+
+// SetCache is implemented by caches that can store unordered sets of unique members.
+// Both built-in drivers implement it; use a type assertion to access it from a Cache.
+type SetCache interface {
+	// SAdd adds the members to the set and returns how many were not already in it.
+	SAdd(key string, members ...string) (int64, error)
+
+	// SRem removes the members from the set and returns how many were removed.
+	SRem(key string, members ...string) (int64, error)
+
+	// SMembers returns the members of the set, sorted lexicographically.
+	SMembers(key string) ([]string, error)
+
+	// SIsMember reports whether the member belongs to the set.
+	SIsMember(key string, member string) (bool, error)
+
+	// SCard returns the number of members of the set.
+	SCard(key string) (int64, error)
+}
+
+// ScoredMember is a member of a sorted set together with its score.
+type ScoredMember = driver.ScoredMember
+
+// SortedSetCache is implemented by caches that can store sets of members ranked by score.
+// Both built-in drivers implement it; use a type assertion to access it from a Cache.
+type SortedSetCache interface {
+	// ZAdd adds the member with the given score, or updates the score of an existing member.
+	ZAdd(key string, member string, score float64) error
+
+	// ZIncrBy increments the score of the member and returns the new score.
+	ZIncrBy(key string, member string, increment float64) (float64, error)
+
+	// ZScore returns the score of the member, and false if the member does not exist.
+	ZScore(key string, member string) (float64, bool, error)
+
+	// ZRem removes the members from the sorted set and returns how many were removed.
+	ZRem(key string, members ...string) (int64, error)
+
+	// ZRange returns the members between the start and stop ranks, inclusive, from the lowest to the highest score.
+	ZRange(key string, start int64, stop int64) ([]ScoredMember, error)
+
+	// ZRevRange returns the members between the start and stop ranks, inclusive, from the highest to the lowest score.
+	ZRevRange(key string, start int64, stop int64) ([]ScoredMember, error)
+
+	// ZCard returns the number of members of the sorted set.
+	ZCard(key string) (int64, error)
+}
+
+// Stats is a snapshot of the statistics of a cache driver.
+type Stats = driver.Stats
+
+// StatsProvider is implemented by caches that keep statistics about their operations.
+// The memory, Redis and disk drivers implement it; use a type assertion to access it from a Cache.
+type StatsProvider interface {
+	// Stats returns the hits, misses, writes, deletions, evictions, expirations, errors,
+	// item count and per-operation latency histograms of the cache.
+	Stats() Stats
+}
+
+// Event describes a change of a cache entry.
+type Event = driver.Event
+
+// EventNotifier is implemented by caches that report the changes of their entries to registered handlers.
+// The memory, Redis and disk drivers implement it; use a type assertion to access it from a Cache.
+// Handlers are invoked outside the cache lock, so they may call back into the cache.
+type EventNotifier interface {
+	// OnSet registers a handler called whenever a value is stored.
+	OnSet(handler driver.EventHandler)
+	// OnDelete registers a handler called whenever an entry is deleted on request.
+	OnDelete(handler driver.EventHandler)
+	// OnExpire registers a handler called whenever an entry is removed because its TTL elapsed.
+	OnExpire(handler driver.EventHandler)
+	// OnEvict registers a handler called whenever the cache itself removes an entry, with the reason why.
+	OnEvict(handler driver.EventHandler)
+}
+
 // NewCache creates a new cache based on the value of the CACHE_TYPE environment variable.
 // If CACHE_TYPE is not set, it defaults to "redis".
 // The function returns a Cache interface that can be used to interact with the cache.
-// The supported cache types are "redis" and "memory".
+// The supported cache types are "redis", "memory" and "disk".
 // For "redis" cache type, it uses the REDIS_ADDR and REDIS_PASSWORD environment variables to connect to the Redis server.
 // If REDIS_DB is set, it uses that value as the Redis database number, otherwise it uses the default database.
-// For "memory" cache type, it takes a time duration as a parameter to specify the expiration time for the cache entries.
+// For "memory" cache type, it creates an in-memory cache.
+// For "disk" cache type, it stores the entries under the CACHE_DIR directory, which defaults to a "go-cache"
+// directory in the temporary directory. If CACHE_MAX_SIZE is set, it limits the total size of the entries in bytes.
+// The options, such as driver.WithLogger, are passed to the selected driver.
 // If an unsupported cache type is specified, the function panics with an error message.
-func NewCache() Cache {
+func NewCache(opts ...driver.Option) Cache {
 	var err error
 	var cache Cache
 	// discover cache type
@@ -77,11 +239,31 @@ func NewCache() Cache {
 		})
 
 		// load cache
-		cache = driver.NewRedisCache(redisClient)
+		cache = driver.NewRedisCache(redisClient, opts...)
 
 	case "memory":
 		// load memory cache
-		cache = driver.NewMemoryCache()
+		cache = driver.NewMemoryCache(opts...)
+
+	case "disk":
+		// load disk cache
+		dir := os.Getenv("CACHE_DIR")
+		if dir == "" {
+			// The user cache directory survives restarts, unlike the temporary directory on many systems
+			base, err := os.UserCacheDir()
+			if err != nil {
+				base = os.TempDir()
+			}
+			dir = filepath.Join(base, "go-cache")
+		}
+		if os.Getenv("CACHE_MAX_SIZE") != "" {
+			maxSize, err := strconv.ParseInt(os.Getenv("CACHE_MAX_SIZE"), 10, 64)
+			if err != nil {
+				panic(err)
+			}
+			opts = append([]driver.Option{driver.WithMaxSize(maxSize)}, opts...)
+		}
+		cache = driver.NewDiskCache(dir, opts...)
 	default:
 		panic("Cache type not supported")
 	}
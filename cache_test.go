@@ -1,10 +1,14 @@
 package cache_test
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/sibeur/go-cache"
+	"github.com/sibeur/go-cache/driver"
 )
 
 func TestNewCache(t *testing.T) {
@@ -17,7 +21,7 @@ func TestNewCache(t *testing.T) {
 
 func TestNewCacheWithRedis(t *testing.T) {
 	os.Setenv("CACHE_TYPE", "redis")
-	os.Setenv("REDIS_ADDR", "localhost:6379")
+	os.Setenv("REDIS_ADDR", testRedisAddr(t))
 	os.Setenv("REDIS_DB", "0")
 	cache := cache.NewCache()
 	if cache.GetDriverName() != "redis" {
@@ -37,7 +41,7 @@ func TestNewCacheWithInvalidType(t *testing.T) {
 
 func TestNewCacheWithInvalidRedisDB(t *testing.T) {
 	os.Setenv("CACHE_TYPE", "redis")
-	os.Setenv("REDIS_ADDR", "localhost:6379")
+	os.Setenv("REDIS_ADDR", testRedisAddr(t))
 	os.Setenv("REDIS_DB", "invalid")
 	defer func() {
 		if r := recover(); r == nil {
@@ -59,7 +63,7 @@ func TestNewCacheWithInvalidRedisAddr(t *testing.T) {
 
 func TestNewCacheWithInvalidRedisPassword(t *testing.T) {
 	os.Setenv("CACHE_TYPE", "redis")
-	os.Setenv("REDIS_ADDR", "localhost:6379")
+	os.Setenv("REDIS_ADDR", testRedisAddr(t))
 	os.Setenv("REDIS_DB", "0")
 	os.Setenv("REDIS_PASSWORD", "invalid")
 	c := cache.NewCache()
@@ -70,7 +74,7 @@ func TestNewCacheWithInvalidRedisPassword(t *testing.T) {
 
 func TestNewCacheWithEmptyRedisPassword(t *testing.T) {
 	os.Setenv("CACHE_TYPE", "redis")
-	os.Setenv("REDIS_ADDR", "localhost:6379")
+	os.Setenv("REDIS_ADDR", testRedisAddr(t))
 	os.Setenv("REDIS_DB", "0")
 	os.Setenv("REDIS_PASSWORD", "")
 	cache := cache.NewCache()
@@ -81,7 +85,7 @@ func TestNewCacheWithEmptyRedisPassword(t *testing.T) {
 
 func TestNewCacheWithEmptyRedisDB(t *testing.T) {
 	os.Setenv("CACHE_TYPE", "redis")
-	os.Setenv("REDIS_ADDR", "localhost:6379")
+	os.Setenv("REDIS_ADDR", testRedisAddr(t))
 	os.Setenv("REDIS_DB", "")
 	cache := cache.NewCache()
 	if cache.GetDriverName() != "redis" {
@@ -93,9 +97,12 @@ func TestNewCacheWithEmptyRedisAddr(t *testing.T) {
 	os.Setenv("CACHE_TYPE", "redis")
 	os.Setenv("REDIS_ADDR", "")
 	os.Setenv("REDIS_DB", "0")
+
+	// The client targets its default address, localhost:6379. Whether a server listens there depends on the
+	// machine, so only the driver is checked, and nothing is written to that server.
 	c := cache.NewCache()
-	if !c.IsCacheAvailable() {
-		t.Errorf("Expected cache available %v, but got %v", true, c.IsCacheAvailable())
+	if c.GetDriverName() != "redis" {
+		t.Errorf("Expected driver name %s, but got %s", "redis", c.GetDriverName())
 	}
 }
 
@@ -106,3 +113,77 @@ func TestNewCacheWithEmptyType(t *testing.T) {
 		t.Errorf("Expected driver name %s, but got %s", "memory", cache.GetDriverName())
 	}
 }
+
+func TestWithContext(t *testing.T) {
+	os.Setenv("CACHE_TYPE", "memory")
+	c := cache.WithContext(cache.NewCache())
+
+	err := c.Set(context.Background(), "key", "value")
+	if err != nil {
+		t.Errorf("Failed to set value: %v", err)
+	}
+	value, err := c.Get(context.Background(), "key")
+	if err != nil || value != "value" {
+		t.Errorf("Expected value %s, but got %s (error %v)", "value", value, err)
+	}
+
+	// A canceled context must stop the operation
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Get(ctx, "key"); err != context.Canceled {
+		t.Errorf("Expected error %v, but got %v", context.Canceled, err)
+	}
+}
+
+func TestNewCacheWithDisk(t *testing.T) {
+	os.Setenv("CACHE_TYPE", "disk")
+	os.Setenv("CACHE_DIR", t.TempDir())
+	os.Setenv("CACHE_MAX_SIZE", "1024")
+	defer os.Unsetenv("CACHE_MAX_SIZE")
+	c := cache.NewCache()
+	if c.GetDriverName() != "disk" {
+		t.Errorf("Expected driver name %s, but got %s", "disk", c.GetDriverName())
+	}
+	if !c.IsCacheAvailable() {
+		t.Errorf("Expected cache available %v, but got %v", true, c.IsCacheAvailable())
+	}
+}
+
+// contextHook is a go-redis hook recording the contexts the commands are sent with.
+type contextHook struct {
+	contexts chan context.Context
+}
+
+func (h contextHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h contextHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.contexts <- ctx
+		return next(ctx, cmd)
+	}
+}
+
+func (h contextHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestWithContext_Redis(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: testRedisAddr(t)})
+	t.Cleanup(func() { _ = client.Close() })
+	c := cache.WithContext(driver.NewRedisCache(client))
+	hook := contextHook{contexts: make(chan context.Context, 10)}
+	client.AddHook(hook)
+
+	// The context of the caller reaches the Redis command, with its deadline
+	type requestKey struct{}
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), requestKey{}, "request1"), time.Minute)
+	defer cancel()
+	_, _ = c.Get(ctx, "key")
+	received := <-hook.contexts
+	if received.Value(requestKey{}) != "request1" {
+		t.Error("Expected the command to be sent with the context of the caller")
+	}
+	if _, ok := received.Deadline(); !ok {
+		t.Error("Expected the command to be sent with the deadline of the caller")
+	}
+}
@@ -0,0 +1,330 @@
+// Package cachetest checks that a cache driver behaves as every cache.Cache must.
+//
+// Call RunConformance from a test of the driver, with a factory returning a new, empty cache:
+//
+//	func TestConformance(t *testing.T) {
+//		cachetest.RunConformance(t, func(t *testing.T) (cache.Cache, cachetest.Advance) {
+//			return mydriver.New(), nil
+//		})
+//	}
+//
+// The suite defines the behavior the built-in drivers share:
+//   - a miss, on a key never stored, deleted, flushed or expired, returns an empty string and driver.ErrMiss,
+//     and so do LPop and RPop on a missing or emptied list if the cache implements cache.ListCache;
+//   - deleting a missing key is not an error;
+//   - a TTL of zero stores the value without expiration, and Set removes the TTL of an existing key;
+//   - the cache is safe for concurrent use;
+//   - SetCacheAvailable changes the availability reported by IsCacheAvailable, from any goroutine;
+//   - an unavailable cache never fails and misses every read, so the callers keep working during an outage;
+//     once available again, it serves the values stored before and left alone during the outage, and never
+//     the values written during it. Whether the deletions made during the outage apply depends on the
+//     degraded mode of the driver, so the suite does not check it.
+package cachetest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/sibeur/go-cache"
+	"github.com/sibeur/go-cache/driver"
+)
+
+// Advance lets the given duration elapse for a cache, so that the entries whose TTL is shorter expire.
+// Drivers relying on a clock they control, such as a fake Redis server, move it forward instantly.
+type Advance func(d time.Duration)
+
+// Factory returns a new, empty cache for a single test of the suite, and the function letting time elapse
+// for it. A nil Advance makes the suite sleep. The factory should release the cache with t.Cleanup.
+type Factory func(t *testing.T) (cache.Cache, Advance)
+
+// RunConformance runs the conformance suite against the caches returned by the factory,
+// each check as a subtest with its own cache.
+func RunConformance(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, c cache.Cache, advance Advance)
+	}{
+		{"GetSet", testGetSet},
+		{"Miss", testMiss},
+		{"Delete", testDelete},
+		{"Flush", testFlush},
+		{"Expire", testExpire},
+		{"NoExpire", testNoExpire},
+		{"Concurrency", testConcurrency},
+		{"Availability", testAvailability},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, advance := factory(t)
+			if advance == nil {
+				advance = time.Sleep
+			}
+			tt.test(t, c, advance)
+		})
+	}
+}
+
+// isMiss reports whether the result of a Get is a miss.
+func isMiss(value string, err error) bool {
+	return value == "" && errors.Is(err, driver.ErrMiss)
+}
+
+// expectValue fails the test unless the key holds the expected value.
+func expectValue(t *testing.T, c cache.Cache, key string, expected string) {
+	t.Helper()
+	value, err := c.Get(key)
+	if err != nil {
+		t.Errorf("Get(%q) failed: %v", key, err)
+	} else if value != expected {
+		t.Errorf("Get(%q) returned %q, expected %q", key, value, expected)
+	}
+}
+
+// expectMiss fails the test unless reading the key is a miss.
+func expectMiss(t *testing.T, c cache.Cache, key string) {
+	t.Helper()
+	if value, err := c.Get(key); !isMiss(value, err) {
+		t.Errorf("Get(%q) returned %q and %v, expected a miss", key, value, err)
+	}
+}
+
+// mustSet stores the value under the key, stopping the test on failure.
+func mustSet(t *testing.T, c cache.Cache, key string, value string) {
+	t.Helper()
+	if err := c.Set(key, value); err != nil {
+		t.Fatalf("Set(%q) failed: %v", key, err)
+	}
+}
+
+// mustSetWithExpire stores the value under the key with the TTL, stopping the test on failure.
+func mustSetWithExpire(t *testing.T, c cache.Cache, key string, value string, ttl uint64) {
+	t.Helper()
+	if err := c.SetWithExpire(key, value, ttl); err != nil {
+		t.Fatalf("SetWithExpire(%q, %d) failed: %v", key, ttl, err)
+	}
+}
+
+func testGetSet(t *testing.T, c cache.Cache, _ Advance) {
+	mustSet(t, c, "key1", "value1")
+	expectValue(t, c, "key1", "value1")
+
+	// Storing a key again replaces its value
+	mustSet(t, c, "key1", "value2")
+	expectValue(t, c, "key1", "value2")
+
+	// Keys are independent of each other
+	mustSetWithExpire(t, c, "key2", "value3", 60)
+	expectValue(t, c, "key1", "value2")
+	expectValue(t, c, "key2", "value3")
+
+	if name := c.GetDriverName(); name == "" {
+		t.Error("GetDriverName returned an empty name")
+	}
+}
+
+func testMiss(t *testing.T, c cache.Cache, _ Advance) {
+	expectMiss(t, c, "missing")
+
+	// Reading a missing key must not create it
+	expectMiss(t, c, "missing")
+
+	lists, ok := c.(cache.ListCache)
+	if !ok {
+		return
+	}
+	for name, pop := range map[string]func(key string) (string, error){"LPop": lists.LPop, "RPop": lists.RPop} {
+		if value, err := pop("missing"); !isMiss(value, err) {
+			t.Errorf("%s(%q) = %q, %v, expected a miss", name, "missing", value, err)
+		}
+		if _, err := lists.RPush("list", "value1"); err != nil {
+			t.Fatalf("RPush failed: %v", err)
+		}
+		if value, err := pop("list"); value != "value1" || err != nil {
+			t.Errorf("%s(%q) = %q, %v, expected value1", name, "list", value, err)
+		}
+		if value, err := pop("list"); !isMiss(value, err) {
+			t.Errorf("%s(%q) = %q, %v, expected a miss once emptied", name, "list", value, err)
+		}
+	}
+}
+
+func testDelete(t *testing.T, c cache.Cache, _ Advance) {
+	mustSet(t, c, "key1", "value1")
+	mustSet(t, c, "key2", "value2")
+
+	if err := c.Delete("key1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	expectMiss(t, c, "key1")
+	expectValue(t, c, "key2", "value2")
+
+	// Deleting a missing key is not an error
+	if err := c.Delete("key1"); err != nil {
+		t.Errorf("Delete of a missing key failed: %v", err)
+	}
+
+	// A deleted key can be stored again
+	mustSet(t, c, "key1", "value3")
+	expectValue(t, c, "key1", "value3")
+}
+
+func testFlush(t *testing.T, c cache.Cache, _ Advance) {
+	mustSet(t, c, "key1", "value1")
+	mustSetWithExpire(t, c, "key2", "value2", 60)
+
+	if err := c.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	expectMiss(t, c, "key1")
+	expectMiss(t, c, "key2")
+
+	// Flushing an empty cache is not an error, and the cache stays usable
+	if err := c.Flush(); err != nil {
+		t.Errorf("Flush of an empty cache failed: %v", err)
+	}
+	mustSet(t, c, "key1", "value3")
+	expectValue(t, c, "key1", "value3")
+}
+
+func testExpire(t *testing.T, c cache.Cache, advance Advance) {
+	mustSetWithExpire(t, c, "short", "value1", 1)
+	mustSetWithExpire(t, c, "long", "value2", 60)
+	mustSetWithExpire(t, c, "renewed", "value3", 1)
+	expectValue(t, c, "short", "value1")
+
+	// Storing a key again replaces its TTL
+	mustSetWithExpire(t, c, "renewed", "value4", 60)
+
+	advance(1500 * time.Millisecond)
+	expectMiss(t, c, "short")
+	expectValue(t, c, "long", "value2")
+	expectValue(t, c, "renewed", "value4")
+
+	// An expired key can be stored again
+	mustSet(t, c, "short", "value5")
+	expectValue(t, c, "short", "value5")
+}
+
+func testNoExpire(t *testing.T, c cache.Cache, advance Advance) {
+	// A TTL of zero stores the value without expiration
+	mustSetWithExpire(t, c, "zero", "value1", 0)
+
+	// Set removes the TTL of an existing key
+	mustSetWithExpire(t, c, "persisted", "value2", 1)
+	mustSet(t, c, "persisted", "value3")
+
+	advance(1500 * time.Millisecond)
+	expectValue(t, c, "zero", "value1")
+	expectValue(t, c, "persisted", "value3")
+}
+
+func testConcurrency(t *testing.T, c cache.Cache, _ Advance) {
+	const workers = 8
+	const iterations = 50
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			own := fmt.Sprintf("worker:%d", w)
+			for i := 0; i < iterations; i++ {
+				// Every worker owns a key and shares another one with the others
+				if err := c.Set(own, fmt.Sprint(i)); err != nil {
+					t.Errorf("Set(%q) failed: %v", own, err)
+					return
+				}
+				if value, err := c.Get(own); err != nil || value != fmt.Sprint(i) {
+					t.Errorf("Get(%q) returned %q and %v, expected %q", own, value, err, fmt.Sprint(i))
+					return
+				}
+				if err := c.SetWithExpire("shared", own, 60); err != nil {
+					t.Errorf("SetWithExpire(%q) failed: %v", "shared", err)
+					return
+				}
+				if value, err := c.Get("shared"); err != nil && !errors.Is(err, driver.ErrMiss) {
+					t.Errorf("Get(%q) returned %q and %v", "shared", value, err)
+					return
+				}
+				if err := c.Delete("shared"); err != nil {
+					t.Errorf("Delete(%q) failed: %v", "shared", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w := 0; w < workers; w++ {
+		expectValue(t, c, fmt.Sprintf("worker:%d", w), fmt.Sprint(iterations-1))
+	}
+	expectMiss(t, c, "shared")
+}
+
+func testAvailability(t *testing.T, c cache.Cache, _ Advance) {
+	if !c.IsCacheAvailable() {
+		t.Fatal("A new cache is not available")
+	}
+	mustSet(t, c, "key1", "value1")
+	mustSet(t, c, "kept", "value")
+
+	c.SetCacheAvailable(false)
+	if c.IsCacheAvailable() {
+		t.Fatal("The cache is still available after SetCacheAvailable(false)")
+	}
+
+	// An unavailable cache never fails and misses every read
+	expectMiss(t, c, "key1")
+	if err := c.Set("key2", "value2"); err != nil {
+		t.Errorf("Set failed while unavailable: %v", err)
+	}
+	if err := c.SetWithExpire("key3", "value3", 60); err != nil {
+		t.Errorf("SetWithExpire failed while unavailable: %v", err)
+	}
+	if err := c.Delete("key1"); err != nil {
+		t.Errorf("Delete failed while unavailable: %v", err)
+	}
+
+	// The writes made while unavailable are not served, and the values left alone are kept
+	c.SetCacheAvailable(true)
+	if !c.IsCacheAvailable() {
+		t.Fatal("The cache is not available after SetCacheAvailable(true)")
+	}
+	expectValue(t, c, "kept", "value")
+	expectMiss(t, c, "key2")
+	expectMiss(t, c, "key3")
+
+	// The availability can be toggled while the cache is in use, such as by a health check
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			c.SetCacheAvailable(i%2 == 1)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = c.IsCacheAvailable()
+			if _, err := c.Get("key1"); err != nil && !errors.Is(err, driver.ErrMiss) {
+				t.Errorf("Get failed while toggling the availability: %v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	if !c.IsCacheAvailable() {
+		t.Error("The cache is not available after the last SetCacheAvailable(true)")
+	}
+
+	// A flush never fails while unavailable either
+	c.SetCacheAvailable(false)
+	if err := c.Flush(); err != nil {
+		t.Errorf("Flush failed while unavailable: %v", err)
+	}
+	c.SetCacheAvailable(true)
+}
@@ -0,0 +1,57 @@
+package cachetest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	cache "github.com/sibeur/go-cache"
+	"github.com/sibeur/go-cache/cachetest"
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestMemoryCache_Conformance(t *testing.T) {
+	cachetest.RunConformance(t, func(t *testing.T) (cache.Cache, cachetest.Advance) {
+		clock := cachetest.NewFakeClock(time.Now())
+		c := driver.NewMemoryCache(driver.WithClock(clock))
+		t.Cleanup(func() { _ = c.Close() })
+		return c, clock.Advance
+	})
+}
+
+func TestRedisCache_Conformance(t *testing.T) {
+	cachetest.RunConformance(t, func(t *testing.T) (cache.Cache, cachetest.Advance) {
+		addr, fake := cachetest.RedisServer(t)
+		advance := time.Sleep
+		if fake != nil {
+			advance = fake.FastForward
+		}
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		t.Cleanup(func() { _ = client.Close() })
+		if err := client.FlushDB(context.Background()).Err(); err != nil {
+			t.Fatalf("Failed to flush the Redis database: %v", err)
+		}
+		return driver.NewRedisCache(client), advance
+	})
+}
+
+func TestFailoverCache_Conformance(t *testing.T) {
+	cachetest.RunConformance(t, func(t *testing.T) (cache.Cache, cachetest.Advance) {
+		fake := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: fake.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+		c := driver.NewFailoverCache(client)
+		t.Cleanup(func() { _ = c.Close() })
+		return c, fake.FastForward
+	})
+}
+
+func TestDiskCache_Conformance(t *testing.T) {
+	cachetest.RunConformance(t, func(t *testing.T) (cache.Cache, cachetest.Advance) {
+		c := driver.NewDiskCache(t.TempDir())
+		t.Cleanup(func() { _ = c.Close() })
+		return c, nil
+	})
+}
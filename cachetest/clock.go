@@ -0,0 +1,83 @@
+package cachetest
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is a driver.Clock whose time only moves when Advance is called.
+// Pass it to the memory driver with driver.WithClock to test expiry without sleeping:
+//
+//	clock := cachetest.NewFakeClock(time.Now())
+//	c := driver.NewMemoryCache(driver.WithClock(clock))
+//	_ = c.SetWithExpire("key", "value", 1)
+//	clock.Advance(2 * time.Second) // "key" is now expired
+//
+// It is safe for concurrent use.
+type FakeClock struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond    // Signaled whenever a waiter is added.
+	now     time.Time     // The current time of the clock.
+	waiters []*fakeWaiter // The pending After calls.
+}
+
+// fakeWaiter is a pending call to FakeClock.After.
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFakeClock returns a fake clock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mutex)
+	return c
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the time of the clock once it has been advanced by at least d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, &fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	c.cond.Broadcast()
+	return ch
+}
+
+// Advance moves the clock forward by d and wakes up the After calls whose duration has elapsed.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
+
+// BlockUntil waits until at least n After calls are pending on the clock.
+// A routine woken up by Advance is known to be done with its work once it waits on the clock again,
+// such as the cleanup routine of the memory driver removing the expired entries.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
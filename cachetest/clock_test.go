@@ -0,0 +1,53 @@
+package cachetest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sibeur/go-cache/cachetest"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := cachetest.NewFakeClock(start)
+
+	short := clock.After(time.Second)
+	long := clock.After(time.Minute)
+	select {
+	case <-clock.After(0):
+	default:
+		t.Error("After(0) did not fire immediately")
+	}
+
+	// Only the waits whose duration elapsed are woken up
+	clock.Advance(2 * time.Second)
+	if now := clock.Now(); !now.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("Expected the clock to read %v, but got %v", start.Add(2*time.Second), now)
+	}
+	select {
+	case now := <-short:
+		if !now.Equal(start.Add(2 * time.Second)) {
+			t.Errorf("Expected the short wait to receive %v, but got %v", start.Add(2*time.Second), now)
+		}
+	default:
+		t.Error("The short wait did not fire")
+	}
+	select {
+	case <-long:
+		t.Error("The long wait fired too early")
+	default:
+	}
+
+	// BlockUntil returns once enough routines wait on the clock, the long wait being the first one
+	done := make(chan struct{})
+	go func() {
+		clock.BlockUntil(2)
+		close(done)
+	}()
+	_ = clock.After(time.Hour)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("BlockUntil did not return")
+	}
+}
@@ -0,0 +1,24 @@
+package cachetest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// RedisAddrEnv names the environment variable pointing the Redis tests at a real server, such as localhost:6379.
+// When it is not set, every test runs against its own in-process fake server.
+const RedisAddrEnv = "REDIS_TEST_ADDR"
+
+// RedisServer returns the address of the Redis server a test runs against: the one named by RedisAddrEnv,
+// or else a new fake server stopped when the test ends. The fake server is returned too, nil for a real server,
+// so that the test can move its clock forward or make it fail.
+func RedisServer(t *testing.T) (string, *miniredis.Miniredis) {
+	t.Helper()
+	if addr := os.Getenv(RedisAddrEnv); addr != "" {
+		return addr, nil
+	}
+	fake := miniredis.RunT(t)
+	return fake.Addr(), fake
+}
@@ -1,12 +1,33 @@
 package common
 
 const (
+	// cache operation name
+	OpGet        = "get"
+	OpSet        = "set"
+	OpDelete     = "delete"
+	OpFlush      = "flush"
+	OpScan       = "scan"
+	OpInvalidate = "invalidate"
+	OpSubscribe  = "subscribe"
+
 	// cache action message
-	SetCacheMsg    = "Set cache"
-	GetCacheMsg    = "Get cache"
-	DeleteCacheMsg = "Delete cache"
-	FlushCacheMsg  = "Flush cache"
+	SetCacheMsg        = "Set cache"
+	GetCacheMsg        = "Get cache"
+	DeleteCacheMsg     = "Delete cache"
+	FlushCacheMsg      = "Flush cache"
+	ScanCacheMsg       = "Scan cache"
+	InvalidateCacheMsg = "Invalidate cache"
+	SubscribeCacheMsg  = "Subscribe cache"
 
 	// error message
 	ErrCacheUnavailableMsg = "Cache is unavailable"
+	ErrMissMsg             = "Key not found in cache"
+	ErrWrongTypeMsg        = "Operation against a key holding the wrong kind of value"
+	ErrSnapshotFormatMsg   = "Snapshot is corrupted or not a cache snapshot"
+	ErrSnapshotVersionMsg  = "Snapshot format version is not supported"
+	ErrLogFormatMsg        = "File is not a cache append-only log"
+	ErrLogVersionMsg       = "Append-only log format version is not supported"
+	ErrDiskEntryFormatMsg  = "Disk cache entry is corrupted"
+	ErrLoadPanicMsg        = "Load panicked"
+	ErrNoValuesMsg         = "No value to add"
 )
@@ -0,0 +1,118 @@
+package cache
+
+import "context"
+
+// ContextCache is the context-aware variant of the Cache operations.
+// Every operation takes a context carrying its deadline, cancellation and trace, so that decorators
+// such as the tracing package can attach the operation to the caller's request.
+type ContextCache interface {
+	// Get retrieves the value associated with the given key from the cache.
+	Get(ctx context.Context, key string) (string, error)
+
+	// Set sets the value associated with the given key in the cache.
+	Set(ctx context.Context, key string, value string) error
+
+	// SetWithExpire sets the value associated with the given key in the cache with a TTL in seconds.
+	SetWithExpire(ctx context.Context, key string, value string, ttl uint64) error
+
+	// Delete deletes the value associated with the given key from the cache.
+	Delete(ctx context.Context, key string) error
+
+	// Flush deletes all the values stored in the cache.
+	Flush(ctx context.Context) error
+
+	// IsCacheAvailable checks if the cache is available for use.
+	IsCacheAvailable() bool
+
+	// GetDriverName returns the name of the cache driver being used.
+	GetDriverName() string
+}
+
+// contextDriver is implemented by the caches whose operations take a context, such as the built-in drivers.
+type contextDriver interface {
+	GetCtx(ctx context.Context, key string) (string, error)
+	SetCtx(ctx context.Context, key string, value string) error
+	SetWithExpireCtx(ctx context.Context, key string, value string, ttl uint64) error
+	DeleteCtx(ctx context.Context, key string) error
+	FlushCtx(ctx context.Context) error
+}
+
+// WithContext adapts the cache to the ContextCache interface.
+// If the cache has context variants of its operations (GetCtx, SetCtx and so on), as the built-in drivers do,
+// the context is passed down to them, so that its deadline and cancellation bound the Redis commands.
+// Otherwise every operation returns the context error without touching the cache if the context is already done.
+func WithContext(c Cache) ContextCache {
+	driver, _ := c.(contextDriver)
+	return &contextCache{cache: c, driver: driver}
+}
+
+// contextCache is the ContextCache returned by WithContext.
+type contextCache struct {
+	cache  Cache
+	driver contextDriver // The cache as a contextDriver, nil if it has no context variants.
+}
+
+// Get retrieves the value associated with the given key from the wrapped cache.
+func (c *contextCache) Get(ctx context.Context, key string) (string, error) {
+	if c.driver != nil {
+		return c.driver.GetCtx(ctx, key)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return c.cache.Get(key)
+}
+
+// Set sets the value associated with the given key in the wrapped cache.
+func (c *contextCache) Set(ctx context.Context, key string, value string) error {
+	if c.driver != nil {
+		return c.driver.SetCtx(ctx, key, value)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.cache.Set(key, value)
+}
+
+// SetWithExpire sets the value associated with the given key in the wrapped cache with a TTL in seconds.
+func (c *contextCache) SetWithExpire(ctx context.Context, key string, value string, ttl uint64) error {
+	if c.driver != nil {
+		return c.driver.SetWithExpireCtx(ctx, key, value, ttl)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.cache.SetWithExpire(key, value, ttl)
+}
+
+// Delete deletes the value associated with the given key from the wrapped cache.
+func (c *contextCache) Delete(ctx context.Context, key string) error {
+	if c.driver != nil {
+		return c.driver.DeleteCtx(ctx, key)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.cache.Delete(key)
+}
+
+// Flush deletes all the values stored in the wrapped cache.
+func (c *contextCache) Flush(ctx context.Context) error {
+	if c.driver != nil {
+		return c.driver.FlushCtx(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.cache.Flush()
+}
+
+// IsCacheAvailable checks if the wrapped cache is available for use.
+func (c *contextCache) IsCacheAvailable() bool {
+	return c.cache.IsCacheAvailable()
+}
+
+// GetDriverName returns the name of the driver of the wrapped cache.
+func (c *contextCache) GetDriverName() string {
+	return c.cache.GetDriverName()
+}
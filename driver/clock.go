@@ -0,0 +1,28 @@
+package driver
+
+import "time"
+
+// Clock tells the time to the memory driver, which uses it to compute and check the expiration of the entries
+// and to schedule the removal of the expired ones, and to cache.ReadThrough, which uses it to tell stale values.
+// Inject a fake clock with WithClock to test expiry without waiting, such as cachetest.FakeClock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock reading the system time, used by default.
+var SystemClock Clock = systemClock{}
+
+// systemClock is the type of SystemClock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
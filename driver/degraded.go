@@ -0,0 +1,68 @@
+package driver
+
+// DegradedMode tells how a driver behaves while it is unavailable, either because its backend could not be
+// reached when it was created or because SetCacheAvailable(false) was called. It is set with WithDegradedMode.
+type DegradedMode int
+
+const (
+	// FailOpen skips the operations: reads find nothing and writes are dropped, without error,
+	// so that the callers keep working on their source of truth. This is the default.
+	// The skipped operations are counted in Stats.Unavailable.
+	FailOpen DegradedMode = iota
+	// FailClosed makes every operation return ErrCacheUnavailable, so that the callers notice the outage.
+	FailClosed
+	// FallbackToMemory serves every operation from a local MemoryCache until the driver is available again.
+	// The local cache is flushed when the driver recovers, so that it never serves values older than the outage.
+	// The Redis driver pings Redis at the WithHealthCheck interval while it could not be reached, and recovers as
+	// soon as it answers; the keys written or deleted locally in the meantime are then deleted from Redis, and the
+	// flushes, pattern, prefix and tag deletions replayed, so that Redis does not serve the values they replaced.
+	// The memory driver has nothing to fall back to and fails open instead.
+	FallbackToMemory
+)
+
+// degradedPolicy applies the DegradedMode of a driver to the operations attempted while it is unavailable.
+type degradedPolicy struct {
+	mode   DegradedMode
+	logger *cacheLogger
+	stats  *statsRecorder
+}
+
+// newDegradedPolicy returns the policy of a driver configured with the given options.
+func newDegradedPolicy(o *options, logger *cacheLogger, stats *statsRecorder) degradedPolicy {
+	return degradedPolicy{mode: o.degradedMode, logger: logger, stats: stats}
+}
+
+// unavailable records an operation skipped because the driver is unavailable and returns the error to report:
+// ErrCacheUnavailable when failing closed, nil otherwise.
+func (p degradedPolicy) unavailable(op string) error {
+	p.logger.unavailable(op)
+	p.stats.unavailable.Add(1)
+	if p.mode == FailClosed {
+		return ErrCacheUnavailable
+	}
+	return nil
+}
+
+// unavailableRead is unavailable for the reads, which miss instead of succeeding when the driver fails open.
+func (p degradedPolicy) unavailableRead(op string) error {
+	if err := p.unavailable(op); err != nil {
+		return err
+	}
+	return ErrMiss
+}
+
+// newFallback returns the local cache serving the operations of an unavailable driver, lengthening its TTLs
+// with the jitter of the driver, or nil unless the mode is FallbackToMemory.
+func (p degradedPolicy) newFallback(o *options, jitter ttlJitter) *MemoryCache {
+	if p.mode != FallbackToMemory {
+		return nil
+	}
+	// The local cache shares the logging, the clock and the TTL jitter of the driver, but none of its persistence.
+	// It has no cleanup routine of its own: the driver removes its expired entries while it is unavailable.
+	// The jitter itself is shared, rather than its options, so that a seeded source is drawn from under one lock.
+	local := newOptions(nil)
+	local.logger, local.redactKeys, local.clock = o.logger, o.redactKeys, o.clock
+	fallback := newMemoryCache(local)
+	fallback.jitter = jitter
+	return fallback
+}
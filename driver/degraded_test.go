@@ -0,0 +1,214 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/cachetest"
+	"github.com/sibeur/go-cache/driver"
+)
+
+// degradableCache is the part of the drivers exercised by the degraded mode tests.
+type degradableCache interface {
+	Get(key string) (string, error)
+	Set(key string, value string) error
+	Delete(key string) error
+	Flush() error
+	SetCacheAvailable(available bool)
+	Stats() driver.Stats
+}
+
+// newDegradableCaches returns a cache of every driver configured with the options.
+func newDegradableCaches(t *testing.T, opts ...driver.Option) map[string]degradableCache {
+	return map[string]degradableCache{
+		"memory": driver.NewMemoryCache(opts...),
+		"redis":  driver.NewRedisCache(newTestRedisClient(t), opts...),
+		"disk":   driver.NewDiskCache(t.TempDir(), opts...),
+	}
+}
+
+func TestDegradedMode_FailOpen(t *testing.T) {
+	for name, cache := range newDegradableCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			_ = cache.Set("key1", "value1")
+			cache.SetCacheAvailable(false)
+
+			// The operations are skipped without error, but counted
+			if value, err := cache.Get("key1"); value != "" || !errors.Is(err, driver.ErrMiss) {
+				t.Errorf("Expected an empty value and ErrMiss, but got %q and %v", value, err)
+			}
+			if err := cache.Set("key2", "value2"); err != nil {
+				t.Errorf("Expected no error, but got %v", err)
+			}
+			if unavailable := cache.Stats().Unavailable; unavailable != 2 {
+				t.Errorf("Expected 2 unavailable operations, but got %d", unavailable)
+			}
+		})
+	}
+}
+
+func TestDegradedMode_FailClosed(t *testing.T) {
+	for name, cache := range newDegradableCaches(t, driver.WithDegradedMode(driver.FailClosed)) {
+		t.Run(name, func(t *testing.T) {
+			_ = cache.Set("key1", "value1")
+			cache.SetCacheAvailable(false)
+
+			// Every operation reports the outage
+			if _, err := cache.Get("key1"); !errors.Is(err, driver.ErrCacheUnavailable) {
+				t.Errorf("Expected ErrCacheUnavailable from Get, but got %v", err)
+			}
+			if err := cache.Set("key2", "value2"); !errors.Is(err, driver.ErrCacheUnavailable) {
+				t.Errorf("Expected ErrCacheUnavailable from Set, but got %v", err)
+			}
+			if err := cache.Delete("key1"); !errors.Is(err, driver.ErrCacheUnavailable) {
+				t.Errorf("Expected ErrCacheUnavailable from Delete, but got %v", err)
+			}
+			if err := cache.Flush(); !errors.Is(err, driver.ErrCacheUnavailable) {
+				t.Errorf("Expected ErrCacheUnavailable from Flush, but got %v", err)
+			}
+
+			// The cache works again once available
+			cache.SetCacheAvailable(true)
+			if value, err := cache.Get("key1"); value != "value1" || err != nil {
+				t.Errorf("Expected value1 and no error, but got %q and %v", value, err)
+			}
+		})
+	}
+}
+
+func TestDegradedMode_FallbackToMemory(t *testing.T) {
+	caches := newDegradableCaches(t, driver.WithDegradedMode(driver.FallbackToMemory))
+	delete(caches, "memory")
+	for name, cache := range caches {
+		t.Run(name, func(t *testing.T) {
+			_ = cache.Set("key1", "value1")
+			cache.SetCacheAvailable(false)
+
+			// The local cache serves the operations during the outage
+			if value, _ := cache.Get("key1"); value != "" {
+				t.Errorf("Expected the local cache to start empty, but got %q", value)
+			}
+			_ = cache.Set("key2", "value2")
+			if value, err := cache.Get("key2"); value != "value2" || err != nil {
+				t.Errorf("Expected value2 from the local cache, but got %q and %v", value, err)
+			}
+
+			// Once recovered, the values stored before are served again and the local ones are dropped
+			cache.SetCacheAvailable(true)
+			if value, _ := cache.Get("key1"); value != "value1" {
+				t.Errorf("Expected value1, but got %q", value)
+			}
+			if value, _ := cache.Get("key2"); value != "" {
+				t.Errorf("Expected the local value to be dropped, but got %q", value)
+			}
+
+			// A new outage starts from an empty local cache
+			cache.SetCacheAvailable(false)
+			if value, _ := cache.Get("key2"); value != "" {
+				t.Errorf("Expected the local cache to be flushed, but got %q", value)
+			}
+		})
+	}
+}
+
+func TestDegradedMode_FallbackToMemory_Recovery(t *testing.T) {
+	if os.Getenv(cachetest.RedisAddrEnv) != "" {
+		t.Skip("Simulating an outage needs the fake Redis server")
+	}
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+	defer client.Close()
+	cache := driver.NewRedisCache(client, driver.WithDegradedMode(driver.FallbackToMemory), driver.WithHealthCheck(10*time.Millisecond))
+	defer cache.Close()
+
+	// The cache starts on the local cache and switches to Redis once it is reachable
+	if cache.IsCacheAvailable() {
+		t.Fatal("Expected the cache to start unavailable")
+	}
+	_ = cache.Set("key1", "local")
+	if err := server.StartAddr(addr); err != nil {
+		t.Fatalf("Failed to start the fake Redis server: %v", err)
+	}
+	server.Set("key1", "stale")
+	deadline := time.Now().Add(2 * time.Second)
+	for !cache.IsCacheAvailable() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the cache to recover once Redis is reachable")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if server.Exists("key1") {
+		t.Error("Expected the key written locally during the outage to be deleted from Redis")
+	}
+
+	// The pattern and tag deletions made during a later outage are replayed too
+	_ = cache.Set("user:1", "alice")
+	_ = cache.SetWithTags("order:1", "pending", 0, "orders")
+	_ = cache.Set("other", "value")
+	cache.SetCacheAvailable(false)
+	_, _ = cache.DeleteByPrefix(context.Background(), "user:")
+	_, _ = cache.InvalidateTags("orders")
+	cache.SetCacheAvailable(true)
+	for _, key := range []string{"user:1", "order:1"} {
+		if server.Exists(key) {
+			t.Errorf("Expected %s to be deleted from Redis", key)
+		}
+	}
+	if value, _ := cache.Get("other"); value != "value" {
+		t.Errorf("Expected the untouched value to be kept, but got %q", value)
+	}
+}
+
+// scriptHook is a go-redis hook calling a function before every script a client runs.
+type scriptHook struct {
+	before func()
+}
+
+func (h scriptHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h scriptHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "evalsha" || cmd.Name() == "eval" {
+			h.before()
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h scriptHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestDegradedMode_FallbackToMemory_ReplayBeforeAvailable(t *testing.T) {
+	client := newTestRedisClient(t)
+	cache := driver.NewRedisCache(client, driver.WithDegradedMode(driver.FallbackToMemory))
+	defer cache.Close()
+	_ = cache.Flush()
+	var availableDuringReplay atomic.Bool
+	client.AddHook(scriptHook{before: func() {
+		if cache.IsCacheAvailable() {
+			availableDuringReplay.Store(true)
+		}
+	}})
+
+	// The deletions of the outage reach Redis before any write made once it is available again
+	cache.SetCacheAvailable(false)
+	_ = cache.Set("key1", "local")
+	_ = cache.Delete("key2")
+	cache.SetCacheAvailable(true)
+	if availableDuringReplay.Load() {
+		t.Error("Expected the cache to stay unavailable while the outage is replayed")
+	}
+	if !cache.IsCacheAvailable() {
+		t.Error("Expected the cache to be available once the outage is replayed")
+	}
+}
@@ -0,0 +1,626 @@
+package driver
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sibeur/go-cache/common"
+)
+
+// An entry file of the DiskCache starts with the diskMagic bytes and the format version byte, followed by the
+// expiration of the entry in Unix milliseconds as a varint (zero if it never expires), the key and the value,
+// both written as their uvarint length followed by their bytes.
+const (
+	diskMagic   = "GCDK" // diskMagic identifies an entry file of the DiskCache.
+	diskVersion = 1      // diskVersion is the version of the format of the entry files.
+	diskFileExt = ".entry"
+)
+
+// diskEntry is the in-memory index record of an entry stored by the DiskCache.
+type diskEntry struct {
+	key        string    // The key of the entry.
+	size       int64     // The size of the entry file.
+	expiration time.Time // The moment the entry expires, zero if it never expires.
+}
+
+// DiskCache represents a cache driver storing every entry in its own file under a directory,
+// for datasets larger than the memory that must survive restarts.
+// Only the keys, sizes and expirations are kept in memory; values are read from disk on every Get.
+// The files are read, written and flushed to disk outside the lock of the index, which is only held to rename
+// and remove them, so that a slow disk operation never blocks the other keys.
+// While it is unavailable, its operations follow the DegradedMode set with WithDegradedMode.
+type DiskCache struct {
+	isAvailable atomic.Bool              // Flag indicating if the cache is available.
+	dir         string                   // The directory holding the entry files.
+	maxSize     int64                    // The maximum total size of the entry files, zero for no limit.
+	entries     map[string]*list.Element // Index of the entries by key; the elements hold *diskEntry values.
+	lru         *list.List               // The entries from the most to the least recently used.
+	size        int64                    // The total size of the entry files.
+	mutex       sync.Mutex               // Mutex for concurrent access to the index, and the renaming and removal of the files.
+	closed      chan struct{}            // Closed by Close to stop the cleanup routine.
+	closeOnce   sync.Once                // Ensures closed is closed once.
+	clock       Clock                    // The clock used to expire the entries.
+	driverName  string                   // The name of the cache driver.
+	logger      *cacheLogger             // Structured logger of the cache operations.
+	stats       *statsRecorder           // Lock-free statistics of the cache operations.
+	degraded    degradedPolicy           // How the cache behaves while it is unavailable.
+	jitter      ttlJitter                // The random delay added to the TTLs.
+	fallback    *MemoryCache             // The local cache serving the operations while unavailable, if any.
+	events      eventHandlers            // Handlers registered for the cache events.
+	pending     []Event                  // Events raised under the lock, dispatched by unlock.
+}
+
+// NewDiskCache creates a new instance of the DiskCache storing its entries under dir, configured by the
+// given options. The directory is created if needed and the entries already stored in it are indexed,
+// dropping the expired and corrupted ones. If the directory cannot be used, the cache is marked as unavailable.
+// A cleanup routine removes the expired entries every second of the clock set with WithClock until Close is called.
+func NewDiskCache(dir string, opts ...Option) *DiskCache {
+	driverName := "disk"
+	o := newOptions(opts)
+	logger := newCacheLogger(driverName, o)
+	logger.info("initiate cache", slog.String("dir", dir))
+	cache := &DiskCache{
+		dir:        dir,
+		maxSize:    o.maxSize,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		closed:     make(chan struct{}),
+		clock:      o.clock,
+		driverName: driverName,
+		logger:     logger,
+		stats:      newStatsRecorder(),
+	}
+	cache.degraded = newDegradedPolicy(o, logger, cache.stats)
+	cache.jitter = newTTLJitter(o)
+	cache.fallback = cache.degraded.newFallback(o, cache.jitter)
+	cache.isAvailable.Store(true)
+	if err := cache.loadIndex(); err != nil {
+		logger.warn(common.ErrCacheUnavailableMsg, slog.Any("error", err))
+		cache.isAvailable.Store(false)
+	}
+	go cache.startCleanup()
+	return cache
+}
+
+// loadIndex creates the directory if needed and indexes the entry files found in it,
+// from the least to the most recently written. Only the subdirectories and files named by path are considered:
+// the expired and corrupted entry files and the temporary files left by an interrupted write are removed,
+// and any other file is left alone.
+func (d *DiskCache) loadIndex() error {
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return err
+	}
+	type found struct {
+		entry   *diskEntry
+		modTime time.Time
+	}
+	var files []found
+	now := d.clock.Now()
+	subdirs, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	for _, subdir := range subdirs {
+		if !subdir.IsDir() || len(subdir.Name()) != 2 || !isLowerHex(subdir.Name()) {
+			continue
+		}
+		dirEntries, err := os.ReadDir(filepath.Join(d.dir, subdir.Name()))
+		if err != nil {
+			return err
+		}
+		for _, dirEntry := range dirEntries {
+			name := dirEntry.Name()
+			path := filepath.Join(d.dir, subdir.Name(), name)
+			if tmp, _, ok := strings.Cut(name, diskFileExt+"."); ok && strings.HasSuffix(name, ".tmp") && isDiskEntryName(subdir.Name(), tmp+diskFileExt) {
+				// Left behind by a write interrupted by a crash
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+				continue
+			}
+			if dirEntry.IsDir() || !isDiskEntryName(subdir.Name(), name) {
+				continue
+			}
+			info, err := dirEntry.Info()
+			if err != nil {
+				return err
+			}
+			entry, err := readDiskHeader(path)
+			if err != nil || d.path(entry.key) != path || (!entry.expiration.IsZero() && !entry.expiration.After(now)) {
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+				continue
+			}
+			entry.size = info.Size()
+			files = append(files, found{entry: entry, modTime: info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		d.entries[f.entry.key] = d.lru.PushFront(f.entry)
+		d.size += f.entry.size
+	}
+	d.stats.items.Store(int64(len(files)))
+	return nil
+}
+
+// readDiskHeader reads the expiration and the key of the entry file at path.
+func readDiskHeader(path string) (*diskEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	header := make([]byte, len(diskMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(diskMagic)]) != diskMagic || header[len(diskMagic)] != diskVersion {
+		return nil, ErrDiskEntryFormat
+	}
+	expiration, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, length)
+	if _, err := io.ReadFull(r, key); err != nil {
+		return nil, err
+	}
+	entry := &diskEntry{key: string(key)}
+	if expiration != 0 {
+		entry.expiration = time.UnixMilli(expiration)
+	}
+	return entry, nil
+}
+
+// isDiskEntryName reports whether name is the name path gives to an entry file in the subdirectory.
+func isDiskEntryName(subdir string, name string) bool {
+	hash, ok := strings.CutSuffix(name, diskFileExt)
+	return ok && len(hash) == 2*sha256.Size && isLowerHex(hash) && hash[:2] == subdir
+}
+
+// isLowerHex reports whether s only holds lowercase hexadecimal digits.
+func isLowerHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// path returns the path of the entry file of the key. Keys are hashed, so that any key maps to a valid
+// file name, and spread over 256 subdirectories to keep the directories small.
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(d.dir, name[:2], name+diskFileExt)
+}
+
+// startCleanup removes the expired entries every second of the clock of the cache until the cache is closed.
+func (d *DiskCache) startCleanup() {
+	for {
+		select {
+		case <-d.closed:
+			return
+		case <-d.clock.After(time.Second):
+			d.cleanupExpired()
+		}
+	}
+}
+
+// cleanupExpired removes all expired entries from the disk cache, and from the fallback cache while unavailable,
+// since it has no cleanup routine of its own.
+func (d *DiskCache) cleanupExpired() {
+	if d.fallback != nil && !d.isAvailable.Load() {
+		d.fallback.cleanupExpired()
+	}
+	d.mutex.Lock()
+	defer d.unlock()
+
+	now := d.clock.Now()
+	for _, element := range d.entries {
+		if entry := element.Value.(*diskEntry); d.isExpired(entry, now) {
+			d.remove(element, EventExpire, "")
+			d.stats.expirations.Add(1)
+		}
+	}
+}
+
+// unlock releases the lock, then dispatches the events raised while it was held.
+func (d *DiskCache) unlock() {
+	events := d.pending
+	d.pending = nil
+	d.mutex.Unlock()
+	d.events.dispatch(events)
+}
+
+// raise queues an event for the key if a handler is registered for it. The caller must hold the lock.
+func (d *DiskCache) raise(eventType EventType, key string, value string, reason EvictReason) {
+	if d.events.wants(eventType) {
+		d.pending = append(d.pending, Event{Type: eventType, Key: key, Value: value, Reason: reason})
+	}
+}
+
+// isExpired checks if the given entry has expired at now.
+func (d *DiskCache) isExpired(entry *diskEntry, now time.Time) bool {
+	return !entry.expiration.IsZero() && entry.expiration.Before(now)
+}
+
+// remove deletes the entry file of the element and drops it from the index, raising an event of the given type.
+// The caller must hold the lock.
+func (d *DiskCache) remove(element *list.Element, eventType EventType, reason EvictReason) {
+	entry := element.Value.(*diskEntry)
+	if err := os.Remove(d.path(entry.key)); err != nil && !os.IsNotExist(err) {
+		d.stats.errors.Add(1)
+		d.logger.warn("cannot remove entry file", slog.Any("error", err))
+	}
+	d.lru.Remove(element)
+	delete(d.entries, entry.key)
+	d.size -= entry.size
+	d.stats.items.Add(-1)
+	d.raise(eventType, entry.key, "", reason)
+}
+
+// encodeDiskEntry returns the content of the entry file of the key.
+func encodeDiskEntry(key string, value string, expiration time.Time) []byte {
+	var expirationMs int64
+	if !expiration.IsZero() {
+		expirationMs = expiration.UnixMilli()
+	}
+	data := append([]byte(diskMagic), diskVersion)
+	data = binary.AppendVarint(data, expirationMs)
+	data = appendSnapshotString(data, key)
+	return appendSnapshotString(data, value)
+}
+
+// store renames the temporary file written by writeTemp to the entry file of the key and indexes it,
+// then evicts the least recently used entries until the total size fits the limit. The temporary file
+// replaces the previous entry file at once, so a crash never leaves a partial entry behind.
+// The caller must hold the lock.
+func (d *DiskCache) store(entry *diskEntry, tmp string, value string) error {
+	key := entry.key
+	if err := os.Rename(tmp, d.path(key)); err != nil {
+		return err
+	}
+
+	if element, ok := d.entries[key]; ok {
+		d.size -= element.Value.(*diskEntry).size
+		element.Value = entry
+		d.lru.MoveToFront(element)
+	} else {
+		d.entries[key] = d.lru.PushFront(entry)
+		d.stats.items.Add(1)
+	}
+	d.size += entry.size
+	d.raise(EventSet, key, value, "")
+
+	for d.maxSize > 0 && d.size > d.maxSize && d.lru.Len() > 1 {
+		d.remove(d.lru.Back(), EventEvict, EvictReasonCapacity)
+		d.stats.evictions.Add(1)
+	}
+	return nil
+}
+
+// writeTemp writes data to a temporary file in the directory of path, creating it if needed, flushes it to disk
+// and returns its name, so that it can be renamed to path.
+func writeTemp(path string, data []byte) (string, error) {
+	dir := filepath.Dir(path)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+		if err := syncDir(filepath.Dir(dir)); err != nil {
+			return "", err
+		}
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// syncDir flushes the directory to disk, so that the files renamed into it survive a crash.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Get retrieves the value associated with the given key from the disk cache.
+// If the key does not exist or has expired, it returns an empty string and ErrMiss.
+// If the cache is unavailable, it logs an error message and returns an empty string.
+func (d *DiskCache) Get(key string) (string, error) {
+	defer d.stats.observe(common.OpGet, time.Now())
+	if !d.isAvailable.Load() {
+		if d.fallback != nil {
+			return d.fallback.Get(key)
+		}
+		d.stats.read(false)
+		return "", d.degraded.unavailableRead(common.OpGet)
+	}
+	d.mutex.Lock()
+	element, ok := d.entries[key]
+	if !ok || d.isExpired(element.Value.(*diskEntry), d.clock.Now()) {
+		d.mutex.Unlock()
+		d.stats.read(false)
+		return "", ErrMiss
+	}
+	d.lru.MoveToFront(element)
+	d.mutex.Unlock()
+
+	data, err := os.ReadFile(d.path(key))
+	if os.IsNotExist(err) {
+		// Deleted since the index was read
+		d.stats.read(false)
+		return "", ErrMiss
+	}
+	if err != nil {
+		return "", d.stats.failed(err)
+	}
+	value, err := decodeDiskValue(data)
+	if err != nil {
+		return "", d.stats.failed(err)
+	}
+	d.stats.read(true)
+	d.logger.operation(common.OpGet, key)
+	return value, nil
+}
+
+// decodeDiskValue returns the value stored in the content of an entry file.
+func decodeDiskValue(data []byte) (string, error) {
+	if len(data) < len(diskMagic)+1 || string(data[:len(diskMagic)]) != diskMagic || data[len(diskMagic)] != diskVersion {
+		return "", ErrDiskEntryFormat
+	}
+	dec := &snapshotDecoder{data: data[len(diskMagic)+1:]}
+	dec.varint()
+	dec.string()
+	value := dec.string()
+	if dec.err != nil {
+		return "", ErrDiskEntryFormat
+	}
+	return value, nil
+}
+
+// Set sets the value for the given key in the disk cache.
+// If the key already exists, its value will be overwritten.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (d *DiskCache) Set(key string, value string) error {
+	if d.fallback != nil && !d.isAvailable.Load() {
+		return d.fallback.Set(key, value)
+	}
+	return d.set(key, value, time.Time{})
+}
+
+// SetWithExpire sets a key-value pair in the disk cache with an expiration time.
+// The TTL is in seconds, zero for no expiration. If the cache is unavailable, it logs an error message and returns nil.
+func (d *DiskCache) SetWithExpire(key string, value string, ttl uint64) error {
+	if d.fallback != nil && !d.isAvailable.Load() {
+		return d.fallback.SetWithExpire(key, value, ttl)
+	}
+	return d.set(key, value, expiresAt(d.clock.Now(), d.jitter.apply(ttl)), slog.Uint64("ttl", ttl))
+}
+
+// set stores the value with the given expiration, zero for none, and logs the operation with the extra attributes.
+// The entry file is written and flushed to disk before the lock is taken, and its directory flushed once released.
+func (d *DiskCache) set(key string, value string, expiration time.Time, attrs ...slog.Attr) error {
+	defer d.stats.observe(common.OpSet, time.Now())
+	if !d.isAvailable.Load() {
+		return d.degraded.unavailable(common.OpSet)
+	}
+	data := encodeDiskEntry(key, value, expiration)
+	path := d.path(key)
+	tmp, err := writeTemp(path, data)
+	if err != nil {
+		return d.stats.failed(err)
+	}
+
+	d.mutex.Lock()
+	err = d.store(&diskEntry{key: key, size: int64(len(data)), expiration: expiration}, tmp, value)
+	d.unlock()
+	if err != nil {
+		os.Remove(tmp)
+		return d.stats.failed(err)
+	}
+	if err := syncDir(filepath.Dir(path)); err != nil {
+		return d.stats.failed(err)
+	}
+	d.stats.sets.Add(1)
+	d.logger.operation(common.OpSet, key, attrs...)
+	return nil
+}
+
+// Delete removes the cache entry with the specified key from the disk cache.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (d *DiskCache) Delete(key string) error {
+	defer d.stats.observe(common.OpDelete, time.Now())
+	if !d.isAvailable.Load() {
+		if d.fallback != nil {
+			return d.fallback.Delete(key)
+		}
+		return d.degraded.unavailable(common.OpDelete)
+	}
+	d.mutex.Lock()
+	defer d.unlock()
+
+	if element, ok := d.entries[key]; ok {
+		if d.isExpired(element.Value.(*diskEntry), d.clock.Now()) {
+			d.remove(element, EventExpire, "")
+		} else {
+			d.remove(element, EventDelete, "")
+			d.stats.deletes.Add(1)
+		}
+	}
+	d.logger.operation(common.OpDelete, key)
+	return nil
+}
+
+// Flush deletes every entry of the disk cache.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (d *DiskCache) Flush() error {
+	defer d.stats.observe(common.OpFlush, time.Now())
+	if !d.isAvailable.Load() {
+		if d.fallback != nil {
+			return d.fallback.Flush()
+		}
+		return d.degraded.unavailable(common.OpFlush)
+	}
+	d.mutex.Lock()
+	defer d.unlock()
+
+	now := d.clock.Now()
+	for _, element := range d.entries {
+		if d.isExpired(element.Value.(*diskEntry), now) {
+			d.remove(element, EventExpire, "")
+		} else {
+			d.remove(element, EventEvict, EvictReasonFlush)
+		}
+	}
+	d.logger.operation(common.OpFlush, "")
+	return nil
+}
+
+// The disk cache cannot interrupt a file operation once started, so the context variants of the operations
+// only refuse to start once the context is done; they are what cache.WithContext calls.
+
+// GetCtx is like Get, but returns the context error instead if the context is done.
+func (d *DiskCache) GetCtx(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return d.Get(key)
+}
+
+// SetCtx is like Set, but returns the context error instead if the context is done.
+func (d *DiskCache) SetCtx(ctx context.Context, key string, value string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.Set(key, value)
+}
+
+// SetWithExpireCtx is like SetWithExpire, but returns the context error instead if the context is done.
+func (d *DiskCache) SetWithExpireCtx(ctx context.Context, key string, value string, ttl uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.SetWithExpire(key, value, ttl)
+}
+
+// DeleteCtx is like Delete, but returns the context error instead if the context is done.
+func (d *DiskCache) DeleteCtx(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.Delete(key)
+}
+
+// FlushCtx is like Flush, but returns the context error instead if the context is done.
+func (d *DiskCache) FlushCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return d.Flush()
+}
+
+// Size returns the total size in bytes of the entry files of the disk cache.
+func (d *DiskCache) Size() int64 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.size
+}
+
+// Stats returns a snapshot of the statistics of the disk cache.
+func (d *DiskCache) Stats() Stats {
+	return d.stats.snapshot()
+}
+
+// OnSet registers a handler called whenever a value is stored.
+func (d *DiskCache) OnSet(handler EventHandler) {
+	d.events.on(EventSet, handler)
+}
+
+// OnDelete registers a handler called whenever an entry is deleted by Delete.
+// The value is not read back from disk, so the event carries none.
+func (d *DiskCache) OnDelete(handler EventHandler) {
+	d.events.on(EventDelete, handler)
+}
+
+// OnExpire registers a handler called whenever an expired entry is removed from the cache.
+// The value is not read back from disk, so the event carries none.
+func (d *DiskCache) OnExpire(handler EventHandler) {
+	d.events.on(EventExpire, handler)
+}
+
+// OnEvict registers a handler called whenever an entry is removed by Flush, or to fit the size limit set by
+// WithMaxSize. The value is not read back from disk, so the event carries none.
+func (d *DiskCache) OnEvict(handler EventHandler) {
+	d.events.on(EventEvict, handler)
+}
+
+// Close stops the cleanup routine and closes the fallback cache, if any.
+// The entries stay on disk for the next DiskCache opened on the directory.
+func (d *DiskCache) Close() error {
+	d.closeOnce.Do(func() { close(d.closed) })
+	if d.fallback != nil {
+		return d.fallback.Close()
+	}
+	return nil
+}
+
+// IsCacheAvailable checks if the cache is available.
+// It returns true if the cache is available, otherwise false.
+func (d *DiskCache) IsCacheAvailable() bool {
+	return d.isAvailable.Load()
+}
+
+// SetCacheAvailable sets the availability of the disk cache.
+// When the cache falls back to memory, the local cache is flushed once the disk cache is available again.
+func (d *DiskCache) SetCacheAvailable(available bool) {
+	if !d.isAvailable.Swap(available) && available && d.fallback != nil {
+		_ = d.fallback.Flush()
+	}
+}
+
+// GetDriverName returns the name of the driver used by the DiskCache.
+func (d *DiskCache) GetDriverName() string {
+	return d.driverName
+}
@@ -0,0 +1,187 @@
+package driver_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sibeur/go-cache/cachetest"
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestDiskCache_Get(t *testing.T) {
+	cache := driver.NewDiskCache(t.TempDir())
+	defer cache.Close()
+
+	// Set a value in the cache
+	if err := cache.Set("key1", "value1"); err != nil {
+		t.Errorf("Failed to set value in cache: %v", err)
+	}
+
+	// Get the value from the cache
+	value, err := cache.Get("key1")
+	if err != nil {
+		t.Errorf("Failed to get value from cache: %v", err)
+	}
+	if value != "value1" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "value1", value)
+	}
+
+	// A missing key returns an empty string
+	if value, err := cache.Get("missing"); value != "" || !errors.Is(err, driver.ErrMiss) {
+		t.Errorf("Expected empty string and ErrMiss, but got %q and %v", value, err)
+	}
+}
+
+func TestDiskCache_SetWithExpire(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Now())
+	cache := driver.NewDiskCache(t.TempDir(), driver.WithClock(clock))
+	defer cache.Close()
+
+	_ = cache.SetWithExpire("key1", "value1", 1)
+	if value, _ := cache.Get("key1"); value != "value1" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "value1", value)
+	}
+
+	// Let the entry expire, then wait for the cleanup routine to remove it
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Second)
+	clock.BlockUntil(1)
+	if value, _ := cache.Get("key1"); value != "" {
+		t.Errorf("Value is not expired: expected empty string, got %s", value)
+	}
+	if size := cache.Size(); size != 0 {
+		t.Errorf("Expected the expired entry file to be removed, but the cache holds %d bytes", size)
+	}
+}
+
+func TestDiskCache_Delete_Flush(t *testing.T) {
+	cache := driver.NewDiskCache(t.TempDir())
+	defer cache.Close()
+
+	_ = cache.Set("key1", "value1")
+	_ = cache.Set("key2", "value2")
+	_ = cache.Set("key3", "value3")
+
+	_ = cache.Delete("key1")
+	if value, _ := cache.Get("key1"); value != "" {
+		t.Errorf("Value is not deleted: expected empty string, got %s", value)
+	}
+	if value, _ := cache.Get("key2"); value != "value2" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "value2", value)
+	}
+
+	_ = cache.Flush()
+	if value, _ := cache.Get("key2"); value != "" {
+		t.Errorf("Value is not flushed: expected empty string, got %s", value)
+	}
+	if items := cache.Stats().Items; items != 0 {
+		t.Errorf("Expected 0 items, but got %d", items)
+	}
+}
+
+func TestDiskCache_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	clock := cachetest.NewFakeClock(time.Now())
+
+	cache := driver.NewDiskCache(dir, driver.WithClock(clock))
+	_ = cache.Set("key1", "value1")
+	_ = cache.SetWithExpire("key2", "value2", 1)
+	_ = cache.Close()
+
+	// Leave a partial write behind, as a crash would, next to the entry file of key1
+	entries, _ := filepath.Glob(filepath.Join(dir, "*", "*.entry"))
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entry files, but got %v", entries)
+	}
+	leftover := entries[0] + ".123.tmp"
+	_ = os.WriteFile(leftover, []byte("partial"), 0o644)
+
+	// Files that do not belong to the cache are left alone, wherever they are
+	foreign := []string{
+		filepath.Join(dir, "notes.tmp"),
+		filepath.Join(dir, "backup.entry"),
+		filepath.Join(filepath.Dir(entries[0]), "other.entry"),
+		filepath.Join(dir, "nested", "deep", "data.tmp"),
+	}
+	for _, path := range foreign {
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		_ = os.WriteFile(path, []byte("foreign"), 0o644)
+	}
+
+	// The short entry expires while the cache is stopped
+	clock.Advance(1100 * time.Millisecond)
+	reopened := driver.NewDiskCache(dir, driver.WithClock(clock))
+	defer reopened.Close()
+
+	if value, _ := reopened.Get("key1"); value != "value1" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "value1", value)
+	}
+	if value, _ := reopened.Get("key2"); value != "" {
+		t.Errorf("Expired value was restored: got %s", value)
+	}
+	if items := reopened.Stats().Items; items != 1 {
+		t.Errorf("Expected 1 item, but got %d", items)
+	}
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Errorf("Expected the partial write to be removed, but got %v", err)
+	}
+	for _, path := range foreign {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be left alone, but got %v", path, err)
+		}
+	}
+}
+
+func TestDiskCache_WithMaxSize(t *testing.T) {
+	value := strings.Repeat("x", 100)
+	cache := driver.NewDiskCache(t.TempDir(), driver.WithMaxSize(350))
+	defer cache.Close()
+	recorder := &eventRecorder{}
+	cache.OnEvict(recorder.handle)
+
+	_ = cache.Set("key1", value)
+	_ = cache.Set("key2", value)
+	_ = cache.Set("key3", value)
+
+	// Reading key1 makes key2 the least recently used entry
+	_, _ = cache.Get("key1")
+	_ = cache.Set("key4", value)
+
+	if got, _ := cache.Get("key2"); got != "" {
+		t.Errorf("Expected key2 to be evicted, but it is still cached")
+	}
+	for _, key := range []string{"key1", "key3", "key4"} {
+		if got, _ := cache.Get(key); got != value {
+			t.Errorf("Expected %s to be kept, but it was evicted", key)
+		}
+	}
+	if size := cache.Size(); size > 350 {
+		t.Errorf("Expected the cache to hold at most 350 bytes, but it holds %d", size)
+	}
+	events := recorder.received()
+	if len(events) != 1 || events[0] != (driver.Event{Type: driver.EventEvict, Key: "key2", Reason: driver.EvictReasonCapacity}) {
+		t.Errorf("Expected a capacity eviction of key2, but got %v", events)
+	}
+	if evictions := cache.Stats().Evictions; evictions != 1 {
+		t.Errorf("Expected 1 eviction, but got %d", evictions)
+	}
+}
+
+func TestDiskCache_UnavailableCache(t *testing.T) {
+	// A regular file cannot be used as the cache directory
+	file := filepath.Join(t.TempDir(), "file")
+	_ = os.WriteFile(file, nil, 0o644)
+	cache := driver.NewDiskCache(file)
+	defer cache.Close()
+
+	if cache.IsCacheAvailable() {
+		t.Errorf("Expected cache available %v, but got %v", false, cache.IsCacheAvailable())
+	}
+	if err := cache.Set("key1", "value1"); err != nil {
+		t.Errorf("Failed to set value in cache: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package driver
+
+import (
+	"errors"
+
+	redis "github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/common"
+)
+
+// ErrMiss is returned with an empty value by every driver when a read finds no value, because the key was never
+// stored, was deleted or expired, or because the driver is unavailable and fails open.
+// It matches redis.Nil with errors.Is, so existing checks for redis.Nil keep working.
+var ErrMiss error = missError{}
+
+// missError is the type of ErrMiss.
+type missError struct{}
+
+func (missError) Error() string {
+	return common.ErrMissMsg
+}
+
+func (missError) Is(target error) bool {
+	return target == redis.Nil
+}
+
+// ErrCacheUnavailable is returned by every operation of an unavailable driver configured to fail closed,
+// see WithDegradedMode.
+var ErrCacheUnavailable = errors.New(common.ErrCacheUnavailableMsg)
+
+// ErrWrongType is returned by the memory driver when an operation targets a key holding a value of another kind,
+// for example a hash operation on a key set with Set. The Redis driver returns the server WRONGTYPE error instead.
+var ErrWrongType = errors.New(common.ErrWrongTypeMsg)
+
+// ErrNoValues is returned by LPush, RPush and SAdd when they are called without values. Redis rejects such calls,
+// so both drivers return it before changing anything.
+var ErrNoValues = errors.New(common.ErrNoValuesMsg)
+
+// ErrSnapshotFormat is returned by MemoryCache.LoadFrom when the data is not a valid snapshot.
+var ErrSnapshotFormat = errors.New(common.ErrSnapshotFormatMsg)
+
+// ErrSnapshotVersion is returned by MemoryCache.LoadFrom when the snapshot was written in an unsupported format version.
+var ErrSnapshotVersion = errors.New(common.ErrSnapshotVersionMsg)
+
+// ErrLogFormat is logged by the memory driver when the file given to WithAppendOnlyLog is not an append-only log.
+// The file is left untouched and the changes are not recorded.
+var ErrLogFormat = errors.New(common.ErrLogFormatMsg)
+
+// ErrLogVersion is logged by the memory driver when the append-only log was written in an unsupported format version.
+// The file is left untouched and the changes are not recorded.
+var ErrLogVersion = errors.New(common.ErrLogVersionMsg)
+
+// ErrDiskEntryFormat is returned by the DiskCache when the file of an entry is corrupted.
+var ErrDiskEntryFormat = errors.New(common.ErrDiskEntryFormatMsg)
@@ -0,0 +1,92 @@
+package driver
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// EventType identifies what happened to a cache entry.
+type EventType string
+
+const (
+	// EventSet is fired when a value is stored under a key.
+	EventSet EventType = "set"
+	// EventDelete is fired when a key is deleted on request.
+	EventDelete EventType = "delete"
+	// EventExpire is fired when a key is removed because its TTL elapsed.
+	EventExpire EventType = "expire"
+	// EventEvict is fired when a key is removed by the cache itself, see the EvictReason values.
+	EventEvict EventType = "evict"
+)
+
+// EvictReason explains why an entry was evicted.
+type EvictReason string
+
+const (
+	// EvictReasonFlush means the entry was removed by Flush.
+	EvictReasonFlush EvictReason = "flush"
+	// EvictReasonInvalidated means the entry was removed by InvalidateTags.
+	EvictReasonInvalidated EvictReason = "invalidated"
+	// EvictReasonCapacity means the entry was removed to make room for other entries.
+	EvictReasonCapacity EvictReason = "capacity"
+)
+
+// Event describes a change of a cache entry.
+type Event struct {
+	Type   EventType   // What happened to the entry.
+	Key    string      // The key of the entry.
+	Value  string      // The stored or removed value when known, empty for non-string values and for Redis events.
+	Reason EvictReason // Why the entry was evicted, only set for EventEvict.
+}
+
+// EventHandler is a callback receiving cache events.
+// Handlers are invoked outside the cache lock, so they may call back into the cache.
+type EventHandler func(Event)
+
+// eventHandlers holds the handlers registered on a cache, by event type.
+type eventHandlers struct {
+	mutex    sync.RWMutex
+	handlers map[EventType][]EventHandler
+	active   atomic.Bool // Whether any handler is registered, checked before building events.
+}
+
+// on registers the handler for the given event type.
+func (e *eventHandlers) on(eventType EventType, handler EventHandler) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.handlers == nil {
+		e.handlers = make(map[EventType][]EventHandler)
+	}
+	e.handlers[eventType] = append(e.handlers[eventType], handler)
+	e.active.Store(true)
+}
+
+// wants reports whether any handler is registered for the given event type.
+func (e *eventHandlers) wants(eventType EventType) bool {
+	if !e.active.Load() {
+		return false
+	}
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return len(e.handlers[eventType]) > 0
+}
+
+// dispatch calls the handlers registered for each event, in order. The handlers of every event are read under
+// the lock, so that handlers may be registered concurrently; since on only appends, the slices read stay unchanged.
+func (e *eventHandlers) dispatch(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	handlers := make([][]EventHandler, len(events))
+	e.mutex.RLock()
+	for i, event := range events {
+		handlers[i] = e.handlers[event.Type]
+	}
+	e.mutex.RUnlock()
+	for i, event := range events {
+		for _, handler := range handlers[i] {
+			handler(event)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// FailoverCache represents a cache driver that uses Redis while it is healthy and switches to a local
+// MemoryCache when it goes down, so that the service keeps some caching during a Redis outage.
+// It is a RedisCache falling back to memory, see FallbackToMemory, that also detects an outage as it happens.
+//
+// Redis is marked down when an operation fails to reach it or a health check fails, and the operation is served
+// by the local cache instead. The health checks ping Redis at the interval set with WithHealthCheck and switch
+// back once it answers again. The keys written or deleted locally during the outage are then deleted from Redis,
+// and the flushes replayed, so that Redis does not serve the values they replaced. With WithFlushOnRecovery,
+// the local cache is flushed on recovery, so that the values written to Redis in the meantime are never shadowed
+// by an older local copy during the next outage.
+type FailoverCache struct {
+	primary    *RedisCache   // The Redis cache, falling back to the local cache while Redis is down.
+	driverName string        // The name of the cache driver.
+	closed     chan struct{} // Closed by Close to stop the health checks.
+	closeOnce  sync.Once     // Ensures closed is closed once.
+}
+
+// NewFailoverCache creates a new instance of FailoverCache using the provided Redis client and options,
+// which are passed to both the Redis and the local cache. If Redis cannot be reached, the cache starts on the
+// local cache. The health checks run in a separate goroutine until Close is called.
+func NewFailoverCache(client *redis.Client, opts ...Option) *FailoverCache {
+	o := newOptions(opts)
+	opts = append(opts[:len(opts):len(opts)], func(o *options) {
+		o.degradedMode = FallbackToMemory
+		o.keepFallback = !o.flushOnRecovery
+	})
+	cache := &FailoverCache{
+		primary:    NewRedisCache(client, opts...),
+		driverName: "failover",
+		closed:     make(chan struct{}),
+	}
+	interval := o.healthInterval
+	if interval <= 0 {
+		interval = defaultHealthInterval
+	}
+	go cache.startHealthCheck(interval)
+	return cache
+}
+
+// startHealthCheck pings Redis at every interval until the cache is closed, marking it down when it does not
+// answer. While Redis is unavailable, the Redis cache runs its own health checks to detect its recovery.
+func (f *FailoverCache) startHealthCheck(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.closed:
+			return
+		case <-ticker.C:
+			if !f.primary.IsCacheAvailable() {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := f.primary.client.Ping(ctx).Err()
+			cancel()
+			if err != nil {
+				f.primary.markDown(err)
+			}
+		}
+	}
+}
+
+// failover runs op on the Redis cache. If op cannot reach Redis, Redis is marked down and op runs again,
+// on the local cache. A failure caused by ctx being done is returned as is, since it says nothing about the
+// health of Redis.
+func failover[T any](ctx context.Context, f *FailoverCache, op func() (T, error)) (T, error) {
+	if f.primary.IsCacheAvailable() {
+		value, err := op()
+		if !isConnectionError(err) || ctx.Err() != nil {
+			return value, err
+		}
+		f.primary.markDown(err)
+	}
+	return op()
+}
+
+// isConnectionError reports whether err means that Redis could not be reached.
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return err != nil && (errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, redis.ErrClosed))
+}
+
+// Get retrieves the value associated with the given key from Redis, or from the local cache while Redis is down.
+func (f *FailoverCache) Get(key string) (string, error) {
+	return f.GetCtx(context.Background(), key)
+}
+
+// GetCtx is like Get, but runs the operation with the given context.
+func (f *FailoverCache) GetCtx(ctx context.Context, key string) (string, error) {
+	return failover(ctx, f, func() (string, error) {
+		return f.primary.GetCtx(ctx, key)
+	})
+}
+
+// Set sets the value for the given key in Redis, or in the local cache while Redis is down.
+func (f *FailoverCache) Set(key string, value string) error {
+	return f.SetCtx(context.Background(), key, value)
+}
+
+// SetCtx is like Set, but runs the operation with the given context.
+func (f *FailoverCache) SetCtx(ctx context.Context, key string, value string) error {
+	_, err := failover(ctx, f, func() (struct{}, error) {
+		return struct{}{}, f.primary.SetCtx(ctx, key, value)
+	})
+	return err
+}
+
+// SetWithExpire sets a key-value pair with a TTL (in seconds) in Redis, or in the local cache while Redis is down.
+func (f *FailoverCache) SetWithExpire(key string, value string, ttl uint64) error {
+	return f.SetWithExpireCtx(context.Background(), key, value, ttl)
+}
+
+// SetWithExpireCtx is like SetWithExpire, but runs the operation with the given context.
+func (f *FailoverCache) SetWithExpireCtx(ctx context.Context, key string, value string, ttl uint64) error {
+	_, err := failover(ctx, f, func() (struct{}, error) {
+		return struct{}{}, f.primary.SetWithExpireCtx(ctx, key, value, ttl)
+	})
+	return err
+}
+
+// Delete removes the cache entry with the specified key from Redis, or from the local cache while Redis is down.
+func (f *FailoverCache) Delete(key string) error {
+	return f.DeleteCtx(context.Background(), key)
+}
+
+// DeleteCtx is like Delete, but runs the operation with the given context.
+func (f *FailoverCache) DeleteCtx(ctx context.Context, key string) error {
+	_, err := failover(ctx, f, func() (struct{}, error) {
+		return struct{}{}, f.primary.DeleteCtx(ctx, key)
+	})
+	return err
+}
+
+// Flush deletes all the keys in Redis, or in the local cache while Redis is down.
+func (f *FailoverCache) Flush() error {
+	return f.FlushCtx(context.Background())
+}
+
+// FlushCtx is like Flush, but runs the operation with the given context.
+func (f *FailoverCache) FlushCtx(ctx context.Context) error {
+	_, err := failover(ctx, f, func() (struct{}, error) {
+		return struct{}{}, f.primary.FlushCtx(ctx)
+	})
+	return err
+}
+
+// Close stops the health checks and closes the Redis and the local cache. It does not close the Redis client.
+func (f *FailoverCache) Close() error {
+	f.closeOnce.Do(func() { close(f.closed) })
+	return f.primary.Close()
+}
+
+// IsCacheAvailable reports whether Redis is available. The FailoverCache itself keeps serving from the local cache
+// while it is not.
+func (f *FailoverCache) IsCacheAvailable() bool {
+	return f.primary.IsCacheAvailable()
+}
+
+// SetCacheAvailable sets the availability of Redis, switching to the local cache when it is false.
+// Unlike an outage detected by the FailoverCache, Redis stays unavailable until SetCacheAvailable(true) is called.
+func (f *FailoverCache) SetCacheAvailable(available bool) {
+	f.primary.SetCacheAvailable(available)
+}
+
+// GetDriverName returns the name of the FailoverCache driver.
+func (f *FailoverCache) GetDriverName() string {
+	return f.driverName
+}
+
+// Current returns the name of the driver currently serving the operations, "redis" or "memory".
+func (f *FailoverCache) Current() string {
+	if f.primary.IsCacheAvailable() {
+		return f.primary.GetDriverName()
+	}
+	return f.primary.fallback.GetDriverName()
+}
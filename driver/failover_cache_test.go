@@ -0,0 +1,204 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/cachetest"
+	"github.com/sibeur/go-cache/driver"
+)
+
+// newFailoverCache returns a failover cache over a fake Redis server that the test can stop and restart.
+func newFailoverCache(t *testing.T, opts ...driver.Option) (*driver.FailoverCache, *miniredis.Miniredis) {
+	t.Helper()
+	if os.Getenv(cachetest.RedisAddrEnv) != "" {
+		t.Skip("Simulating an outage needs the fake Redis server")
+	}
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{
+		Addr:        server.Addr(),
+		MaxRetries:  -1,
+		DialTimeout: 100 * time.Millisecond,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+	opts = append([]driver.Option{driver.WithHealthCheck(10 * time.Millisecond)}, opts...)
+	cache := driver.NewFailoverCache(client, opts...)
+	t.Cleanup(func() { _ = cache.Close() })
+	return cache, server
+}
+
+// waitForDriver waits until the failover cache serves from the given driver.
+func waitForDriver(t *testing.T, cache *driver.FailoverCache, name string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for cache.Current() != name {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the cache to switch to %s, but it still uses %s", name, cache.Current())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFailoverCache_Outage(t *testing.T) {
+	cache, server := newFailoverCache(t)
+
+	_ = cache.Set("key1", "value1")
+	if value, _ := server.Get("key1"); value != "value1" {
+		t.Errorf("Expected the value to be stored in Redis, but got %q", value)
+	}
+
+	// The first operation failing to reach Redis is served by the local cache
+	server.Close()
+	if err := cache.Set("key2", "value2"); err != nil {
+		t.Errorf("Failed to set value during the outage: %v", err)
+	}
+	if current := cache.Current(); current != "memory" {
+		t.Errorf("Expected the cache to switch to memory, but it uses %s", current)
+	}
+	if cache.IsCacheAvailable() {
+		t.Error("Expected Redis to be reported unavailable")
+	}
+	if value, err := cache.Get("key2"); value != "value2" || err != nil {
+		t.Errorf("Expected value2 from the local cache, but got %q and %v", value, err)
+	}
+
+	// The health check switches back to Redis once it answers again
+	if err := server.Restart(); err != nil {
+		t.Fatalf("Failed to restart the fake Redis server: %v", err)
+	}
+	waitForDriver(t, cache, "redis")
+	if value, _ := cache.Get("key1"); value != "value1" {
+		t.Errorf("Expected value1 from Redis, but got %q", value)
+	}
+
+	// Without WithFlushOnRecovery, the local copy is kept for the next outage
+	server.Close()
+	waitForDriver(t, cache, "memory")
+	if value, _ := cache.Get("key2"); value != "value2" {
+		t.Errorf("Expected the local value to be kept, but got %q", value)
+	}
+}
+
+func TestFailoverCache_FlushOnRecovery(t *testing.T) {
+	cache, server := newFailoverCache(t, driver.WithFlushOnRecovery())
+
+	server.Close()
+	waitForDriver(t, cache, "memory")
+	_ = cache.Set("key1", "local")
+
+	if err := server.Restart(); err != nil {
+		t.Fatalf("Failed to restart the fake Redis server: %v", err)
+	}
+	waitForDriver(t, cache, "redis")
+	_ = cache.Set("key1", "redis")
+
+	// The next outage must not serve the local copy written before the recovery
+	server.Close()
+	waitForDriver(t, cache, "memory")
+	if value, _ := cache.Get("key1"); value != "" {
+		t.Errorf("Expected the local cache to be flushed on recovery, but got %q", value)
+	}
+}
+
+func TestFailoverCache_SetCacheAvailable(t *testing.T) {
+	cache, server := newFailoverCache(t)
+
+	// Redis made unavailable on purpose stays unavailable although it answers the health checks
+	cache.SetCacheAvailable(false)
+	_ = cache.Set("key1", "value1")
+	time.Sleep(50 * time.Millisecond)
+	if current := cache.Current(); current != "memory" {
+		t.Errorf("Expected the cache to stay on memory, but it uses %s", current)
+	}
+	if server.Exists("key1") {
+		t.Error("Expected the value to be stored in the local cache only")
+	}
+
+	cache.SetCacheAvailable(true)
+	if current := cache.Current(); current != "redis" {
+		t.Errorf("Expected the cache to switch to redis, but it uses %s", current)
+	}
+}
+
+func TestFailoverCache_StartsDown(t *testing.T) {
+	if os.Getenv(cachetest.RedisAddrEnv) != "" {
+		t.Skip("Simulating an outage needs the fake Redis server")
+	}
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+	defer client.Close()
+	cache := driver.NewFailoverCache(client, driver.WithHealthCheck(10*time.Millisecond))
+	defer cache.Close()
+
+	// The cache starts on the local cache and switches to Redis once it is reachable
+	if current := cache.Current(); current != "memory" {
+		t.Errorf("Expected the cache to start on memory, but it uses %s", current)
+	}
+	if err := server.StartAddr(addr); err != nil {
+		t.Fatalf("Failed to start the fake Redis server: %v", err)
+	}
+	waitForDriver(t, cache, "redis")
+}
+
+func TestFailoverCache_ContextDone(t *testing.T) {
+	cache, _ := newFailoverCache(t)
+
+	// A request giving up is not a Redis outage
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if err := cache.SetCtx(ctx, "key1", "value1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v, but got %v", context.DeadlineExceeded, err)
+	}
+	if current := cache.Current(); current != "redis" {
+		t.Errorf("Expected the cache to stay on redis, but it uses %s", current)
+	}
+}
+
+func TestFailoverCache_ReplaysOutage(t *testing.T) {
+	cache, server := newFailoverCache(t)
+
+	_ = cache.Set("key1", "value1")
+	_ = cache.Set("key2", "value2")
+	_ = cache.Set("key3", "value3")
+
+	// The deletion and the new value only reach the local cache during the outage
+	server.Close()
+	waitForDriver(t, cache, "memory")
+	_ = cache.Delete("key1")
+	_ = cache.Set("key2", "local")
+
+	// Redis must not serve the values they replaced once it recovers
+	if err := server.Restart(); err != nil {
+		t.Fatalf("Failed to restart the fake Redis server: %v", err)
+	}
+	waitForDriver(t, cache, "redis")
+	for _, key := range []string{"key1", "key2"} {
+		if value, err := cache.Get(key); value != "" || !errors.Is(err, driver.ErrMiss) {
+			t.Errorf("Expected %s to miss, but got %q and %v", key, value, err)
+		}
+	}
+	if value, _ := cache.Get("key3"); value != "value3" {
+		t.Errorf("Expected value3 from Redis, but got %q", value)
+	}
+
+	// A flush during the outage is replayed too
+	server.Close()
+	waitForDriver(t, cache, "memory")
+	_ = cache.Flush()
+	if err := server.Restart(); err != nil {
+		t.Fatalf("Failed to restart the fake Redis server: %v", err)
+	}
+	waitForDriver(t, cache, "redis")
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("Expected the flush to be replayed on Redis, but it holds %v", keys)
+	}
+}
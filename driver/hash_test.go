@@ -0,0 +1,74 @@
+package driver_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sibeur/go-cache/cachetest"
+	"github.com/sibeur/go-cache/driver"
+)
+
+// hashCache is the part of the drivers exercised by the cross-driver hash tests.
+type hashCache interface {
+	HGet(key string, field string) (string, error)
+	HGetAll(key string) (map[string]string, error)
+	HSetWithExpire(key string, fields map[string]string, ttl uint64) error
+	HDel(key string, fields ...string) error
+}
+
+// hashCase is a hash cache together with the function letting time elapse for it.
+type hashCase struct {
+	cache   hashCache
+	advance func(time.Duration)
+}
+
+// newHashCaches returns an empty cache of every driver supporting hashes.
+func newHashCaches(t *testing.T) map[string]hashCase {
+	clock := cachetest.NewFakeClock(time.Now())
+	client, server := newTestRedis(t)
+	redisCache := driver.NewRedisCache(client)
+	_ = redisCache.Flush()
+	return map[string]hashCase{
+		"memory": {driver.NewMemoryCache(driver.WithClock(clock)), clock.Advance},
+		"redis":  {redisCache, server.fastForward},
+	}
+}
+
+func TestHashCache_Miss(t *testing.T) {
+	for name, c := range newHashCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			// A missing hash and a missing field are both misses
+			if value, err := c.cache.HGet("user:1", "name"); value != "" || !errors.Is(err, driver.ErrMiss) {
+				t.Errorf("Expected an empty value and ErrMiss for a missing hash, but got %q and %v", value, err)
+			}
+			_ = c.cache.HSetWithExpire("user:1", map[string]string{"name": "alice", "email": "alice@example.com"}, 60)
+			_ = c.cache.HDel("user:1", "email")
+			if value, err := c.cache.HGet("user:1", "email"); value != "" || !errors.Is(err, driver.ErrMiss) {
+				t.Errorf("Expected an empty value and ErrMiss for a missing field, but got %q and %v", value, err)
+			}
+			if value, err := c.cache.HGet("user:1", "name"); value != "alice" || err != nil {
+				t.Errorf("Expected alice and no error, but got %q and %v", value, err)
+			}
+		})
+	}
+}
+
+func TestHashCache_HSetWithExpire_ZeroTTL(t *testing.T) {
+	for name, c := range newHashCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			// A TTL of zero stores the hash without expiration, and removes the TTL of an existing hash
+			_ = c.cache.HSetWithExpire("user:1", map[string]string{"name": "alice"}, 0)
+			_ = c.cache.HSetWithExpire("user:2", map[string]string{"name": "bob"}, 1)
+			_ = c.cache.HSetWithExpire("user:2", map[string]string{"email": "bob@example.com"}, 0)
+
+			c.advance(2 * time.Second)
+			if fields, err := c.cache.HGetAll("user:1"); err != nil || fields["name"] != "alice" {
+				t.Errorf("Expected the hash to be kept, but got %v and %v", fields, err)
+			}
+			if fields, err := c.cache.HGetAll("user:2"); err != nil || len(fields) != 2 {
+				t.Errorf("Expected the hash to be persisted, but got %v and %v", fields, err)
+			}
+		})
+	}
+}
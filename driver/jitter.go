@@ -0,0 +1,61 @@
+package driver
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ttlJitter lengthens the TTLs given to a driver by a random delay, so that the entries written together
+// with the same TTL do not all expire at the same moment.
+type ttlJitter struct {
+	fraction float64       // The bound of the delay as a share of the TTL, zero if the bound is absolute.
+	absolute time.Duration // The absolute bound of the delay, zero if the bound is a share of the TTL.
+	random   *rand.Rand    // The random delays, nil for the global source of math/rand/v2.
+}
+
+// newTTLJitter returns the jitter set in the options, drawing its delays from the source set with
+// WithTTLJitterSource, if any. It adds no delay if WithoutTTLJitter is set.
+func newTTLJitter(o *options) ttlJitter {
+	if o.noJitter {
+		return ttlJitter{}
+	}
+	j := o.ttlJitter
+	if o.jitterSource != nil {
+		j.random = rand.New(&lockedSource{source: o.jitterSource})
+	}
+	return j
+}
+
+// apply returns the TTL (in seconds) as a duration lengthened by a random delay up to the bound of the jitter.
+// A TTL of zero, which means no expiration, is kept as is.
+func (j ttlJitter) apply(ttl uint64) time.Duration {
+	d := time.Duration(ttl) * time.Second
+	if d <= 0 {
+		return d
+	}
+	bound := j.absolute
+	if j.fraction > 0 {
+		bound = time.Duration(j.fraction * float64(d))
+	}
+	if bound <= 0 {
+		return d
+	}
+	if j.random != nil {
+		return d + time.Duration(j.random.Int64N(int64(bound)+1))
+	}
+	return d + rand.N(bound+1)
+}
+
+// lockedSource makes a random source safe for the concurrent writes of a driver.
+type lockedSource struct {
+	mutex  sync.Mutex
+	source rand.Source
+}
+
+// Uint64 returns the next value of the source.
+func (s *lockedSource) Uint64() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.source.Uint64()
+}
@@ -0,0 +1,122 @@
+package driver_test
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sibeur/go-cache/cachetest"
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestTTLJitter_Redis(t *testing.T) {
+	client := newTestRedisClient(t)
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		opt  driver.Option
+		max  time.Duration
+	}{
+		{"fraction", driver.WithTTLJitter(0.5), 15 * time.Second},
+		{"duration", driver.WithTTLJitterDuration(2 * time.Second), 12 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := driver.NewRedisCache(client, tt.opt)
+			_ = cache.Flush()
+
+			// Every TTL is lengthened by up to the bound, and the TTLs differ
+			ttls := make(map[time.Duration]bool)
+			for i := 0; i < 20; i++ {
+				key := "key" + strconv.Itoa(i)
+				_ = cache.SetWithExpire(key, "value", 10)
+				ttl := client.PTTL(ctx, key).Val()
+				// A few milliseconds may pass between the write and PTTL, hence the second of tolerance
+				if ttl < 9*time.Second || ttl > tt.max {
+					t.Fatalf("Expected a TTL between 9s and %v, but got %v", tt.max, ttl)
+				}
+				ttls[ttl] = true
+			}
+			if len(ttls) < 2 {
+				t.Errorf("Expected the TTLs to be spread, but got %v", ttls)
+			}
+
+			// Values without expiration are left as is
+			_ = cache.SetWithExpire("persistent", "value", 0)
+			if ttl := client.PTTL(ctx, "persistent").Val(); ttl != -1 {
+				t.Errorf("Expected no expiration, but got %v", ttl)
+			}
+		})
+	}
+}
+
+func TestTTLJitter_Memory(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Now())
+	newCache := func() *driver.MemoryCache {
+		cache := driver.NewMemoryCache(driver.WithTTLJitterDuration(2*time.Second), driver.WithClock(clock),
+			driver.WithTTLJitterSource(rand.NewPCG(1, 2)))
+		t.Cleanup(func() { _ = cache.Close() })
+		for i := 0; i < 20; i++ {
+			_ = cache.SetWithExpire("key"+strconv.Itoa(i), "value", 1)
+		}
+		return cache
+	}
+	cache, seeded := newCache(), newCache()
+
+	// Past the TTL, the entries whose expiration was delayed are still there, the same with the same seed
+	clock.Advance(2 * time.Second)
+	n, _ := cache.Len(context.Background())
+	if n == 0 || n == 20 {
+		t.Errorf("Expected some entries to outlive their TTL, but got %d", n)
+	}
+	if m, _ := seeded.Len(context.Background()); m != n {
+		t.Errorf("Expected the same seed to keep %d entries, but got %d", n, m)
+	}
+
+	// Past the bound of the jitter, every entry expired
+	clock.Advance(2 * time.Second)
+	if n, _ := cache.Len(context.Background()); n != 0 {
+		t.Errorf("Expected every entry to expire, but got %d", n)
+	}
+}
+
+func TestTTLJitter_Disabled(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Now())
+	cache := driver.NewMemoryCache(driver.WithoutTTLJitter(), driver.WithTTLJitterDuration(2*time.Second),
+		driver.WithClock(clock))
+	defer cache.Close()
+	for i := 0; i < 20; i++ {
+		_ = cache.SetWithExpire("key"+strconv.Itoa(i), "value", 1)
+	}
+
+	// The jitter set after WithoutTTLJitter is ignored too, so every entry expires with its TTL
+	clock.Advance(time.Second + time.Millisecond)
+	if n, _ := cache.Len(context.Background()); n != 0 {
+		t.Errorf("Expected every entry to expire, but got %d", n)
+	}
+}
+
+func TestTTLJitter_Fallback(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Now())
+	cache := driver.NewRedisCache(newTestRedisClient(t), driver.WithDegradedMode(driver.FallbackToMemory),
+		driver.WithTTLJitterDuration(2*time.Second), driver.WithClock(clock), driver.WithTTLJitterSource(rand.NewPCG(1, 2)))
+	defer cache.Close()
+
+	// The TTLs written to the local cache during an outage are lengthened too
+	cache.SetCacheAvailable(false)
+	for i := 0; i < 20; i++ {
+		_ = cache.SetWithExpire("key"+strconv.Itoa(i), "value", 1)
+	}
+	clock.Advance(2 * time.Second)
+	live := 0
+	for i := 0; i < 20; i++ {
+		if value, _ := cache.Get("key" + strconv.Itoa(i)); value != "" {
+			live++
+		}
+	}
+	if live == 0 || live == 20 {
+		t.Errorf("Expected some local entries to outlive their TTL, but got %d", live)
+	}
+}
@@ -0,0 +1,131 @@
+package driver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/sibeur/go-cache/common"
+)
+
+// unavailableLogInterval is the minimum delay between two warnings about the operations skipped while the cache
+// is unavailable, so that an outage does not flood the logs.
+const unavailableLogInterval = 10 * time.Second
+
+// operationMsgs maps every operation name to the message it is logged with.
+var operationMsgs = map[string]string{
+	common.OpGet:        common.GetCacheMsg,
+	common.OpSet:        common.SetCacheMsg,
+	common.OpDelete:     common.DeleteCacheMsg,
+	common.OpFlush:      common.FlushCacheMsg,
+	common.OpScan:       common.ScanCacheMsg,
+	common.OpInvalidate: common.InvalidateCacheMsg,
+	common.OpSubscribe:  common.SubscribeCacheMsg,
+}
+
+// cacheLogger emits the structured logs of a cache driver.
+type cacheLogger struct {
+	logger     *slog.Logger // The logger receiving the records.
+	driverName string       // The name of the driver, attached to every record.
+	redactKeys bool         // Whether keys are hashed before being logged.
+
+	skipped      atomic.Uint64 // The skipped operations not reported by a warning yet.
+	lastSkipWarn atomic.Int64  // When the last warning about skipped operations was logged, in Unix nanoseconds.
+}
+
+// newCacheLogger creates the logger of the named driver from its options.
+func newCacheLogger(driverName string, o *options) *cacheLogger {
+	return &cacheLogger{
+		logger:     o.logger.With(slog.String("driver", driverName)),
+		driverName: driverName,
+		redactKeys: o.redactKeys,
+	}
+}
+
+// operation logs a cache operation on the given key at debug level, with optional extra attributes.
+// An empty key is omitted. Nothing is allocated when the debug level is disabled.
+func (l *cacheLogger) operation(op string, key string, attrs ...slog.Attr) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	all := make([]slog.Attr, 0, len(attrs)+2)
+	all = append(all, slog.String("operation", op))
+	if key != "" {
+		all = append(all, slog.String("key", l.redact(key)))
+	}
+	all = append(all, attrs...)
+	l.logger.LogAttrs(ctx, slog.LevelDebug, operationMsgs[op], all...)
+}
+
+// unavailable logs that an operation was skipped because the cache is unavailable. It logs at warn level at most
+// once every unavailableLogInterval, with the number of operations skipped since the previous warning,
+// and at debug level otherwise.
+func (l *cacheLogger) unavailable(op string) {
+	ctx := context.Background()
+	skipped := l.skipped.Add(1)
+	now := time.Now().UnixNano()
+	last := l.lastSkipWarn.Load()
+	if now-last < int64(unavailableLogInterval) || !l.lastSkipWarn.CompareAndSwap(last, now) {
+		if l.logger.Enabled(ctx, slog.LevelDebug) {
+			l.logger.LogAttrs(ctx, slog.LevelDebug, common.ErrCacheUnavailableMsg, slog.String("operation", op))
+		}
+		return
+	}
+	l.skipped.Add(-skipped)
+	l.logger.LogAttrs(ctx, slog.LevelWarn, common.ErrCacheUnavailableMsg, slog.String("operation", op),
+		slog.Uint64("skipped", skipped))
+}
+
+// info logs a driver lifecycle message at info level.
+func (l *cacheLogger) info(msg string, attrs ...slog.Attr) {
+	l.logger.LogAttrs(context.Background(), slog.LevelInfo, msg, attrs...)
+}
+
+// warn logs a driver problem at warn level.
+func (l *cacheLogger) warn(msg string, attrs ...slog.Attr) {
+	l.logger.LogAttrs(context.Background(), slog.LevelWarn, msg, attrs...)
+}
+
+// redact returns the key as it must appear in the logs.
+func (l *cacheLogger) redact(key string) string {
+	if !l.redactKeys {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// tags returns the attribute listing the tags of an operation, redacted like the keys.
+// The tags are only hashed if the record is actually logged.
+func (l *cacheLogger) tags(tags []string) slog.Attr {
+	return slog.Any("tags", redactedTags{logger: l, tags: tags})
+}
+
+// redactedTags is a slog.LogValuer resolving to the tags as they must appear in the logs.
+type redactedTags struct {
+	logger *cacheLogger
+	tags   []string
+}
+
+func (t redactedTags) LogValue() slog.Value {
+	if !t.logger.redactKeys {
+		return slog.AnyValue(t.tags)
+	}
+	redacted := make([]string, len(t.tags))
+	for i, tag := range t.tags {
+		redacted[i] = t.logger.redact(tag)
+	}
+	return slog.AnyValue(redacted)
+}
+
+// discardHandler is a slog.Handler dropping every record.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
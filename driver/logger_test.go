@@ -0,0 +1,113 @@
+package driver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/sibeur/go-cache/driver"
+)
+
+// decodeLogs parses the JSON log records written to buf.
+func decodeLogs(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to decode log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestMemoryCache_WithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cache := driver.NewMemoryCache(driver.WithLogger(logger))
+
+	_ = cache.SetWithExpire("key1", "value1", 10)
+
+	// The last record describes the operation with structured fields
+	records := decodeLogs(t, &buf)
+	if len(records) == 0 {
+		t.Fatalf("Expected log records, but got none")
+	}
+	record := records[len(records)-1]
+	expected := map[string]interface{}{"driver": "memory", "operation": "set", "key": "key1", "ttl": float64(10)}
+	for field, value := range expected {
+		if record[field] != value {
+			t.Errorf("Expected field %s to be %v, but got %v", field, value, record[field])
+		}
+	}
+}
+
+func TestMemoryCache_WithLogger_LevelGating(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	cache := driver.NewMemoryCache(driver.WithLogger(logger))
+	buf.Reset()
+
+	// Operations are logged at debug level and must be dropped
+	_ = cache.Set("key1", "value1")
+	_, _ = cache.Get("key1")
+	if buf.Len() != 0 {
+		t.Errorf("Expected no log output, but got %s", buf.String())
+	}
+}
+
+func TestMemoryCache_WithRedactedKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cache := driver.NewMemoryCache(driver.WithLogger(logger), driver.WithRedactedKeys())
+
+	_ = cache.Set("user:alice@example.com", "value1")
+
+	if strings.Contains(buf.String(), "alice@example.com") {
+		t.Errorf("Expected key to be redacted, but got %s", buf.String())
+	}
+	records := decodeLogs(t, &buf)
+	key, _ := records[len(records)-1]["key"].(string)
+	if !strings.HasPrefix(key, "sha256:") {
+		t.Errorf("Expected redacted key, but got %s", key)
+	}
+}
+
+func TestMemoryCache_WithLogger_Unavailable(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	cache := driver.NewMemoryCache(driver.WithLogger(logger))
+	cache.SetCacheAvailable(false)
+
+	// The operations skipped during an outage are reported above the debug level, without flooding the logs
+	for i := 0; i < 10; i++ {
+		_, _ = cache.Get("key1")
+	}
+	records := decodeLogs(t, &buf)
+	if len(records) != 1 || records[0]["level"] != "WARN" || records[0]["operation"] != "get" {
+		t.Errorf("Expected a single warning for the skipped gets, but got %v", records)
+	}
+}
+
+func TestMemoryCache_WithRedactedKeys_Tags(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cache := driver.NewMemoryCache(driver.WithLogger(logger), driver.WithRedactedKeys())
+
+	_ = cache.SetWithTags("key1", "value1", 0, "user:alice@example.com")
+	_, _ = cache.InvalidateTags("user:alice@example.com")
+
+	if strings.Contains(buf.String(), "alice@example.com") {
+		t.Errorf("Expected tags to be redacted, but got %s", buf.String())
+	}
+	records := decodeLogs(t, &buf)
+	tags, _ := records[len(records)-1]["tags"].([]interface{})
+	if len(tags) != 1 || !strings.HasPrefix(tags[0].(string), "sha256:") {
+		t.Errorf("Expected redacted tags, but got %v", tags)
+	}
+}
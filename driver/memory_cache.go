@@ -1,51 +1,99 @@
 package driver
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sibeur/go-cache/common"
 )
 
+// memoryItem is a single entry stored in the MemoryCache.
+type memoryItem struct {
+	value      interface{} // The cached value.
+	expiration time.Time   // The moment the entry expires, zero if it never expires.
+	tags       []string    // The tags the entry was stored with.
+}
+
 // MemoryCache represents an in-memory cache implementation.
+// While it is unavailable, its operations follow the DegradedMode set with WithDegradedMode.
 type MemoryCache struct {
-	isAvailable bool                   // Flag indicating if the cache is available.
-	data        map[string]interface{} // The actual cache data stored as key-value pairs.
-	mutex       sync.RWMutex           // Mutex for concurrent access to the cache.
-	expire      time.Duration          // The duration after which cache entries expire.
-	cleanup     *time.Timer            // Timer for periodic cache cleanup.
-	cleanupM    sync.Mutex             // Mutex for synchronizing cache cleanup.
-	driverName  string                 // The name of the cache driver.
-}
-
-// NewMemoryCache creates a new instance of the MemoryCache with the specified expiration time.
-// The cache is initialized with an empty data map, a cleanup timer, and the driver name set to "memory".
-// The cleanup timer is started in a separate goroutine to periodically remove expired entries from the cache.
-func NewMemoryCache() *MemoryCache {
+	isAvailable atomic.Bool                    // Flag indicating if the cache is available.
+	data        map[string]*memoryItem         // The actual cache data stored as key-value pairs.
+	index       *prefixIndex                   // Prefix index over the keys of data.
+	tags        map[string]map[string]struct{} // Reverse index from a tag to the keys stored with it.
+	mutex       sync.RWMutex                   // Mutex for concurrent access to the cache.
+	expire      time.Duration                  // The interval between two removals of the expired entries.
+	clock       Clock                          // The clock used to expire the entries.
+	driverName  string                         // The name of the cache driver.
+	logger      *cacheLogger                   // Structured logger of the cache operations.
+	stats       *statsRecorder                 // Lock-free statistics of the cache operations.
+	degraded    degradedPolicy                 // How the cache behaves while it is unavailable.
+	jitter      ttlJitter                      // The random delay added to the TTLs.
+	events      eventHandlers                  // Handlers registered for the cache events.
+	pending     []Event                        // Events raised under the write lock, dispatched by unlock.
+	aof         *appendOnlyLog                 // Append-only log recording the changes, nil if disabled.
+	changes     []logChange                    // Changes made under the write lock, recorded in aof or journal by unlock.
+	journal     *outageJournal                 // Changes made while serving as the fallback of an unavailable driver, nil otherwise.
+	closed      chan struct{}                  // Closed by Close to stop the periodic snapshots.
+	closeOnce   sync.Once                      // Ensures closed is closed once.
+	background  sync.WaitGroup                 // Tracks the periodic snapshot routine, waited for by Close.
+}
+
+// NewMemoryCache creates a new instance of the MemoryCache configured by the given options.
+// The cache is initialized with an empty data map, a cleanup interval, and the driver name set to "memory".
+// The cleanup routine is started in a separate goroutine to periodically remove expired entries from the cache,
+// until the cache is closed.
+func NewMemoryCache(opts ...Option) *MemoryCache {
+	o := newOptions(opts)
+	cache := newMemoryCache(o)
+	go cache.startCleanup()
+	if o.snapshotPath != "" {
+		cache.restoreSnapshot(o.snapshotPath, o.snapshotInterval)
+	}
+	if o.logPath != "" {
+		if err := cache.openLog(o.logPath, o.logFsync); err != nil {
+			cache.logger.warn("cannot open append-only log", slog.String("path", o.logPath), slog.Any("error", err))
+		}
+	}
+	return cache
+}
+
+// newMemoryCache creates an empty MemoryCache configured by the options, without starting its cleanup routine
+// nor its persistence. It is what the drivers falling back to memory use, removing the expired entries themselves.
+func newMemoryCache(o *options) *MemoryCache {
 	driverName := "memory"
-	log.Printf("[%s] initiate cache", driverName)
+	logger := newCacheLogger(driverName, o)
+	logger.info("initiate cache")
 	cache := &MemoryCache{
-		data:        make(map[string]interface{}),
-		expire:      time.Second,
-		cleanup:     time.NewTimer(time.Second),
-		isAvailable: true,
-		driverName:  driverName,
+		data:       make(map[string]*memoryItem),
+		index:      newPrefixIndex(),
+		tags:       make(map[string]map[string]struct{}),
+		expire:     time.Second,
+		clock:      o.clock,
+		driverName: driverName,
+		logger:     logger,
+		stats:      newStatsRecorder(),
+		closed:     make(chan struct{}),
 	}
-	go cache.startCleanup()
+	cache.isAvailable.Store(true)
+	cache.degraded = newDegradedPolicy(o, logger, cache.stats)
+	cache.jitter = newTTLJitter(o)
 	return cache
 }
 
 // startCleanup starts the cleanup routine for the MemoryCache.
-// It continuously listens for the cleanup channel and performs
-// cache cleanup by calling the cleanupExpired method and resetting
-// the cleanup timer.
+// It waits for the cleanup interval to elapse on the clock of the cache and performs
+// cache cleanup by calling the cleanupExpired method, until the cache is closed.
 func (c *MemoryCache) startCleanup() {
 	for {
 		select {
-		case <-c.cleanup.C:
+		case <-c.closed:
+			return
+		case <-c.clock.After(c.expire):
 			c.cleanupExpired()
-			c.cleanup.Reset(c.expire)
 		}
 	}
 }
@@ -53,102 +101,428 @@ func (c *MemoryCache) startCleanup() {
 // cleanupExpired removes all expired entries from the memory cache.
 func (c *MemoryCache) cleanupExpired() {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlock()
 
-	now := time.Now()
-	for key := range c.data {
-		if c.isExpired(key, now) {
-			delete(c.data, key)
+	now := c.clock.Now()
+	for key, item := range c.data {
+		if c.isExpired(item, now) {
+			c.remove(key, EventExpire, "")
+			c.stats.expirations.Add(1)
 		}
 	}
 }
 
-// isExpired checks if the cache entry with the given key has expired.
+// unlock queues the changes made under the write lock for the append-only log, or records them in the outage
+// journal, releases the lock, writes them to the log, then dispatches the events raised while the lock was held,
+// so that event handlers may call back into the cache. Writing and flushing the log happens outside the lock,
+// so that it never blocks the readers.
+func (c *MemoryCache) unlock() {
+	l := c.aof
+	var batch uint64
+	if l != nil {
+		batch = c.queueLog()
+	} else if c.journal != nil {
+		c.journal.record(c.changes)
+		c.changes = c.changes[:0]
+	}
+	events := c.pending
+	c.pending = nil
+	c.mutex.Unlock()
+	if batch != 0 {
+		c.commitLog(l, batch)
+	}
+	c.events.dispatch(events)
+}
+
+// raise queues the event of the given type for the item stored under the given key,
+// if a handler is registered for it. The caller must hold the write lock.
+func (c *MemoryCache) raise(eventType EventType, key string, item *memoryItem, reason EvictReason) {
+	if !c.events.wants(eventType) {
+		return
+	}
+	value, _ := item.value.(string)
+	c.pending = append(c.pending, Event{Type: eventType, Key: key, Value: value, Reason: reason})
+}
+
+// store saves the item under the given key and keeps the prefix and tag indexes up to date.
+// The caller must hold the write lock.
+func (c *MemoryCache) store(key string, item *memoryItem) {
+	if old, ok := c.data[key]; ok {
+		c.untag(key, old)
+	} else {
+		c.index.insert(key)
+		c.stats.items.Add(1)
+	}
+	c.data[key] = item
+	c.tag(key, item)
+	c.touch(key)
+	c.raise(EventSet, key, item, "")
+}
+
+// remove deletes the item stored under the given key and keeps the prefix and tag indexes up to date.
+// It raises an event of the given type, or EventExpire if the item had already expired.
+// The caller must hold the write lock.
+func (c *MemoryCache) remove(key string, eventType EventType, reason EvictReason) {
+	item, ok := c.data[key]
+	if !ok {
+		return
+	}
+	delete(c.data, key)
+	c.index.remove(key)
+	c.untag(key, item)
+	c.stats.items.Add(-1)
+	c.touch(key)
+	if eventType != EventExpire && c.isExpired(item, c.clock.Now()) {
+		eventType, reason = EventExpire, ""
+	}
+	c.raise(eventType, key, item, reason)
+}
+
+// lookupValue returns the value of type T stored under the given key if it is live.
+// It returns the zero value of T if the key does not exist or has expired,
+// and ErrWrongType if the key holds a value of another type.
+// The caller must hold the lock.
+func lookupValue[T any](c *MemoryCache, key string) (T, error) {
+	var zero T
+	item, ok := c.data[key]
+	if !ok || c.isExpired(item, c.clock.Now()) {
+		return zero, nil
+	}
+	value, ok := item.value.(T)
+	if !ok {
+		return zero, ErrWrongType
+	}
+	return value, nil
+}
+
+// isExpired checks if the given cache entry has expired.
 // It compares the expiration time of the cache entry with the current time.
 // Returns true if the cache entry has expired, false otherwise.
-func (c *MemoryCache) isExpired(key string, now time.Time) bool {
-	expiration, ok := c.data[key+"_expiration"].(time.Time)
-	return ok && expiration.Before(now)
+func (c *MemoryCache) isExpired(item *memoryItem, now time.Time) bool {
+	return !item.expiration.IsZero() && item.expiration.Before(now)
+}
+
+// expiresAt returns the moment an entry stored at now with the given TTL expires,
+// or the zero time if the TTL is zero and the entry never expires, as with Redis.
+func expiresAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl == 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
 }
 
 // Set sets the value for the given key in the memory cache.
 // If the key already exists, its value will be overwritten.
 // The method is thread-safe.
+// If the cache is unavailable, it logs an error message and returns nil.
 func (c *MemoryCache) Set(key string, value string) error {
+	defer c.stats.observe(common.OpSet, time.Now())
+	if !c.isAvailable.Load() {
+		return c.degraded.unavailable(common.OpSet)
+	}
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlock()
 
-	c.data[key] = value
-	log.Printf("[%s] %s %s\n", c.driverName, common.SetCacheMsg, key)
+	c.store(key, &memoryItem{value: value})
+	c.stats.sets.Add(1)
+	c.logger.operation(common.OpSet, key)
 	return nil
 }
 
 // SetWithExpire sets a key-value pair in the memory cache with an expiration time.
 // The key-value pair will be stored in the cache for the specified TTL (time to live in seconds) duration.
 // After the TTL duration has passed, the key-value pair will be automatically evicted from the cache.
+// A TTL of zero stores the key-value pair without expiration.
 // The method acquires a lock on the cache to ensure thread safety during the operation.
-// The key-value pair is stored in the `data` map, and the expiration time is stored in a separate entry in the map.
+// The key-value pair is stored in the `data` map together with its expiration time.
 // The method logs the cache operation with the driver name, the key, and the TTL.
 // If an error occurs during the operation, it will be returned.
+// If the cache is unavailable, it logs an error message and returns nil.
 func (c *MemoryCache) SetWithExpire(key string, value string, ttl uint64) error {
-
+	defer c.stats.observe(common.OpSet, time.Now())
+	if !c.isAvailable.Load() {
+		return c.degraded.unavailable(common.OpSet)
+	}
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlock()
 
-	c.data[key] = value
-	c.data[key+"_expiration"] = time.Now().Add(time.Duration(ttl) * time.Second)
-	log.Printf("[%s] %s %s with TTL %d\n", c.driverName, common.SetCacheMsg, key, ttl)
+	c.store(key, &memoryItem{
+		value:      value,
+		expiration: expiresAt(c.clock.Now(), c.jitter.apply(ttl)),
+	})
+	c.stats.sets.Add(1)
+	c.logger.operation(common.OpSet, key, slog.Uint64("ttl", ttl))
 	return nil
 }
 
 // Get retrieves the value associated with the given key from the memory cache.
-// If the key does not exist or the value is not of type string, it returns an empty string and no error.
+// If the key does not exist or has expired, it returns an empty string and ErrMiss.
+// If the value is not of type string, it returns ErrWrongType.
 // It also logs the cache message with the driver name and key.
+// If the cache is unavailable, it logs an error message and returns an empty string.
 func (c *MemoryCache) Get(key string) (string, error) {
+	defer c.stats.observe(common.OpGet, time.Now())
+	if !c.isAvailable.Load() {
+		c.stats.read(false)
+		return "", c.degraded.unavailableRead(common.OpGet)
+	}
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
-	value, ok := c.data[key].(string)
+	item, ok := c.data[key]
+	if !ok || c.isExpired(item, c.clock.Now()) {
+		c.stats.read(false)
+		return "", ErrMiss
+	}
+	value, ok := item.value.(string)
 	if !ok {
-		return "", nil
+		return "", c.stats.failed(ErrWrongType)
 	}
-	log.Printf("[%s] %s %s\n", c.driverName, common.GetCacheMsg, key)
+	c.stats.read(true)
+	c.logger.operation(common.OpGet, key)
 	return value, nil
 }
 
 // Delete removes the cache entry with the specified key from the memory cache.
 // It acquires a lock to ensure thread safety and then deletes the entry from the cache.
 // Finally, it logs the deletion operation.
+// If the cache is unavailable, it logs an error message and returns nil.
 func (c *MemoryCache) Delete(key string) error {
+	defer c.stats.observe(common.OpDelete, time.Now())
+	if !c.isAvailable.Load() {
+		return c.degraded.unavailable(common.OpDelete)
+	}
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlock()
 
-	delete(c.data, key)
-	log.Printf("[%s] %s %s\n", c.driverName, common.DeleteCacheMsg, key)
+	if item, ok := c.data[key]; ok && !c.isExpired(item, c.clock.Now()) {
+		c.stats.deletes.Add(1)
+	}
+	c.remove(key, EventDelete, "")
+	c.logger.operation(common.OpDelete, key)
 	return nil
 }
 
+// DeleteByPattern removes every entry whose key matches the glob-style pattern and returns how many were removed.
+// The pattern follows the Redis SCAN MATCH rules. Only the keys sharing the literal prefix of the pattern
+// are visited, so patterns such as "tenant:42:*" do not walk the whole cache.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	defer c.stats.observe(common.OpDelete, time.Now())
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpDelete)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+	var removed int64
+	for _, key := range c.index.keysWithPrefix(literalPrefix(pattern)) {
+		if !matchPattern(pattern, key) {
+			continue
+		}
+		if !c.isExpired(c.data[key], now) {
+			removed++
+		}
+		c.remove(key, EventDelete, "")
+	}
+	c.stats.deletes.Add(uint64(removed))
+	c.logger.operation(common.OpDelete, pattern)
+	return removed, nil
+}
+
+// DeleteByPrefix removes every entry whose key starts with the given prefix and returns how many were removed.
+// The prefix is matched literally, it is not interpreted as a pattern.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) DeleteByPrefix(ctx context.Context, prefix string) (int64, error) {
+	defer c.stats.observe(common.OpDelete, time.Now())
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpDelete)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+	var removed int64
+	for _, key := range c.index.keysWithPrefix(prefix) {
+		if !c.isExpired(c.data[key], now) {
+			removed++
+		}
+		c.remove(key, EventDelete, "")
+	}
+	c.stats.deletes.Add(uint64(removed))
+	c.logger.operation(common.OpDelete, prefix+"*")
+	return removed, nil
+}
+
 // Flush clears the cache by resetting the data map to an empty map.
 // It also logs a flush cache message.
+// If the cache is unavailable, it logs an error message and returns nil.
 func (c *MemoryCache) Flush() error {
+	defer c.stats.observe(common.OpFlush, time.Now())
+	if !c.isAvailable.Load() {
+		return c.degraded.unavailable(common.OpFlush)
+	}
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlock()
 
-	c.data = make(map[string]interface{})
-	log.Printf("[%s] %s\n", c.driverName, common.FlushCacheMsg)
+	c.clear()
+	c.logger.operation(common.OpFlush, "")
 	return nil
 }
 
+// The memory cache never waits on the network, so the context variants of the operations only refuse
+// to start once the context is done; they are what cache.WithContext calls.
+
+// GetCtx is like Get, but returns the context error instead if the context is done.
+func (c *MemoryCache) GetCtx(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return c.Get(key)
+}
+
+// SetCtx is like Set, but returns the context error instead if the context is done.
+func (c *MemoryCache) SetCtx(ctx context.Context, key string, value string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Set(key, value)
+}
+
+// SetWithExpireCtx is like SetWithExpire, but returns the context error instead if the context is done.
+func (c *MemoryCache) SetWithExpireCtx(ctx context.Context, key string, value string, ttl uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.SetWithExpire(key, value, ttl)
+}
+
+// DeleteCtx is like Delete, but returns the context error instead if the context is done.
+func (c *MemoryCache) DeleteCtx(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Delete(key)
+}
+
+// FlushCtx is like Flush, but returns the context error instead if the context is done.
+func (c *MemoryCache) FlushCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Flush()
+}
+
+// clear removes every entry from the cache.
+// The caller must hold the write lock.
+func (c *MemoryCache) clear() {
+	now := c.clock.Now()
+	for key, item := range c.data {
+		if !c.isExpired(item, now) {
+			c.raise(EventEvict, key, item, EvictReasonFlush)
+		}
+	}
+	c.data = make(map[string]*memoryItem)
+	c.index = newPrefixIndex()
+	c.tags = make(map[string]map[string]struct{})
+	c.stats.items.Store(0)
+	if c.aof != nil || c.journal != nil {
+		c.changes = append(c.changes, logChange{flush: true})
+	}
+}
+
+// Scan calls fn for every live key in the memory cache matching the glob-style pattern.
+// The pattern follows the Redis SCAN MATCH rules; an empty pattern matches every key.
+// Iteration stops as soon as fn returns false or the context is done.
+// The keys are collected under a read lock, so fn may safely call back into the cache.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (c *MemoryCache) Scan(ctx context.Context, pattern string, fn func(key string) bool) error {
+	defer c.stats.observe(common.OpScan, time.Now())
+	if !c.isAvailable.Load() {
+		return c.degraded.unavailable(common.OpScan)
+	}
+	c.mutex.RLock()
+	now := c.clock.Now()
+	keys := make([]string, 0, len(c.data))
+	for key, item := range c.data {
+		if !c.isExpired(item, now) && matchPattern(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	c.mutex.RUnlock()
+
+	c.logger.operation(common.OpScan, pattern)
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !fn(key) {
+			break
+		}
+	}
+	return nil
+}
+
+// Len returns the number of live entries stored in the memory cache.
+// Entries that have expired but were not yet removed by the cleanup routine are not counted.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) Len(ctx context.Context) (int64, error) {
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpScan)
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	now := c.clock.Now()
+	var count int64
+	for _, item := range c.data {
+		if !c.isExpired(item, now) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Stats returns a snapshot of the statistics of the memory cache.
+// The counters are maintained atomically, so reading them never takes the cache lock.
+func (c *MemoryCache) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+// OnSet registers a handler called whenever a value is stored, including hash, list, set and sorted set creation.
+func (c *MemoryCache) OnSet(handler EventHandler) {
+	c.events.on(EventSet, handler)
+}
+
+// OnDelete registers a handler called whenever an entry is deleted by Delete, a pattern or prefix deletion,
+// or the removal of the last element of a hash, list, set or sorted set.
+func (c *MemoryCache) OnDelete(handler EventHandler) {
+	c.events.on(EventDelete, handler)
+}
+
+// OnExpire registers a handler called whenever an expired entry is removed from the cache.
+// Expired entries are removed by the cleanup routine every second, or earlier by a deletion touching them.
+func (c *MemoryCache) OnExpire(handler EventHandler) {
+	c.events.on(EventExpire, handler)
+}
+
+// OnEvict registers a handler called whenever an entry is removed by Flush or InvalidateTags.
+// The reason of the eviction is set on the event.
+func (c *MemoryCache) OnEvict(handler EventHandler) {
+	c.events.on(EventEvict, handler)
+}
+
 // IsCacheAvailable checks if the cache is available.
 // It returns true if the cache is available, otherwise false.
 func (c *MemoryCache) IsCacheAvailable() bool {
-	return c.isAvailable
+	return c.isAvailable.Load()
 }
 
 // SetCacheAvailable sets the availability of the memory cache.
 // If available is true, the cache is marked as available. Otherwise, it is marked as unavailable.
 func (c *MemoryCache) SetCacheAvailable(available bool) {
-	c.isAvailable = available
+	c.isAvailable.Store(available)
 }
 
 // GetDriverName returns the name of the driver used by the MemoryCache.
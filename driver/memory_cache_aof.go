@@ -0,0 +1,439 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FsyncPolicy tells when the append-only log of the memory driver is flushed to disk.
+type FsyncPolicy int
+
+const (
+	// FsyncEverySec flushes the log once per second, so a crash loses at most the last second of writes.
+	FsyncEverySec FsyncPolicy = iota
+	// FsyncAlways flushes the log after every write, before the write returns.
+	FsyncAlways
+	// FsyncNever leaves flushing the log to the operating system.
+	FsyncNever
+)
+
+// An append-only log starts with the logMagic bytes and the format version byte, followed by records.
+// A record is its payload length as a uvarint, the payload and the big-endian CRC-32 (IEEE) of the payload.
+// The payload is the record kind followed, for logPut, by the key, the value kind, the expiration in Unix
+// milliseconds as a varint (zero if the entry never expires), the tags and the value encoded like in a
+// snapshot; for logDelete, by the key; for logFlush, by nothing.
+const (
+	logMagic   = "GCAO" // logMagic identifies a MemoryCache append-only log.
+	logVersion = 1      // logVersion is the version of the format written by the append-only log.
+)
+
+// Kinds of the records of an append-only log.
+const (
+	logPut byte = iota + 1
+	logDelete
+	logFlush
+)
+
+const (
+	// logRewriteMinSize is the size below which the append-only log is never compacted.
+	logRewriteMinSize = 1 << 20
+	// logRewriteGrowth is how many times the size of the log after its last compaction it must reach to be compacted again.
+	logRewriteGrowth = 2
+)
+
+// logChange is a change made under the write lock that must be recorded in the append-only log.
+type logChange struct {
+	key   string // The changed key, whose current state is recorded.
+	flush bool   // Whether the whole cache was flushed, in which case key is unused.
+}
+
+// appendOnlyLog is the append-only log file of a MemoryCache.
+// The records are queued under the write lock of the cache, in the order of the changes, and written to the file
+// once the lock is released. Whoever writes the file writes every queued record, so that concurrent writers share
+// a single write and flush to disk.
+type appendOnlyLog struct {
+	path   string
+	policy FsyncPolicy
+
+	queueMutex sync.Mutex // Guards queue and queued.
+	queue      []byte     // The records queued and not written yet.
+	queued     uint64     // The number of the last batch of records queued.
+
+	mutex    sync.Mutex    // Guards the fields below.
+	file     *os.File      // The log file, positioned at its end.
+	size     int64         // The current size of the log file.
+	baseSize int64         // The size of the log file after it was last compacted or opened.
+	rewrite  *bytes.Buffer // The records appended while a compaction runs, nil otherwise.
+	written  uint64        // The number of the last batch of records written to the file.
+	dirty    atomic.Bool   // Whether records were appended since the last flush to disk.
+	done     chan struct{} // Closed to stop the background routine.
+}
+
+// touch marks the key as changed, so that its new state is queued for the append-only log by unlock,
+// or the key recorded in the outage journal. The caller must hold the write lock.
+func (c *MemoryCache) touch(key string) {
+	if c.aof == nil && c.journal == nil {
+		return
+	}
+	if n := len(c.changes); n > 0 && !c.changes[n-1].flush && c.changes[n-1].key == key {
+		return
+	}
+	c.changes = append(c.changes, logChange{key: key})
+}
+
+// queueLog queues the records of the changes made under the write lock for the append-only log, and returns
+// the number of their batch to pass to commitLog, or zero if nothing changed. The caller must hold the write lock.
+func (c *MemoryCache) queueLog() uint64 {
+	if len(c.changes) == 0 {
+		return 0
+	}
+	var records []byte
+	for _, change := range c.changes {
+		var payload []byte
+		switch item, ok := c.data[change.key]; {
+		case change.flush:
+			payload = []byte{logFlush}
+		case ok:
+			payload = appendLogPut([]byte{logPut}, change.key, item)
+		default:
+			payload = appendSnapshotString([]byte{logDelete}, change.key)
+		}
+		records = appendLogRecord(records, payload)
+	}
+	c.changes = c.changes[:0]
+	return c.aof.enqueue(records)
+}
+
+// commitLog writes the queued records of the log up to the given batch, unless another writer already did.
+// It is called without the write lock.
+func (c *MemoryCache) commitLog(l *appendOnlyLog, batch uint64) {
+	if err := l.commit(batch); err != nil {
+		c.stats.errors.Add(1)
+		c.logger.warn("cannot write append-only log", slog.String("path", l.path), slog.Any("error", err))
+	}
+}
+
+// appendLogPut appends the key and the item of a logPut record to b.
+func appendLogPut(b []byte, key string, item *memoryItem) []byte {
+	var expiration int64
+	if !item.expiration.IsZero() {
+		expiration = item.expiration.UnixMilli()
+	}
+	b = appendSnapshotString(b, key)
+	b = append(b, snapshotKind(item.value))
+	b = binary.AppendVarint(b, expiration)
+	b = binary.AppendUvarint(b, uint64(len(item.tags)))
+	for _, tag := range item.tags {
+		b = appendSnapshotString(b, tag)
+	}
+	return appendSnapshotValue(b, item.value)
+}
+
+// appendLogRecord appends the framed record of the payload to b.
+func appendLogRecord(b []byte, payload []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(payload)))
+	b = append(b, payload...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(payload))
+}
+
+// enqueue queues the records to be written to the log file and returns the number of their batch.
+func (l *appendOnlyLog) enqueue(records []byte) uint64 {
+	l.queueMutex.Lock()
+	defer l.queueMutex.Unlock()
+
+	l.queue = append(l.queue, records...)
+	l.queued++
+	return l.queued
+}
+
+// commit writes the queued records to the log file, unless the given batch was already written.
+func (l *appendOnlyLog) commit(batch uint64) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.written >= batch {
+		return nil
+	}
+	return l.writeQueued()
+}
+
+// writeQueued writes the queued records to the log file, and flushes them to disk if the policy is FsyncAlways.
+// The caller must hold l.mutex.
+func (l *appendOnlyLog) writeQueued() error {
+	l.queueMutex.Lock()
+	records := l.queue
+	l.queue = nil
+	batch := l.queued
+	l.queueMutex.Unlock()
+
+	l.written = batch
+	if len(records) == 0 {
+		return nil
+	}
+	if l.rewrite != nil {
+		l.rewrite.Write(records)
+	}
+	n, err := l.file.Write(records)
+	l.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if l.policy == FsyncAlways {
+		return l.file.Sync()
+	}
+	l.dirty.Store(true)
+	return nil
+}
+
+// openLog replays the append-only log at path, creating it if needed, then records every change to it.
+// A torn or corrupted tail, left by a crash in the middle of a write, is truncated.
+// It is called by NewMemoryCache when the WithAppendOnlyLog option is set.
+func (c *MemoryCache) openLog(path string, policy FsyncPolicy) error {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if len(data) == 0 {
+		data = append([]byte(logMagic), logVersion)
+		if _, err := file.Write(data); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if len(data) < len(logMagic)+1 || string(data[:len(logMagic)]) != logMagic {
+		file.Close()
+		return ErrLogFormat
+	}
+	if data[len(logMagic)] != logVersion {
+		file.Close()
+		return ErrLogVersion
+	}
+
+	c.mutex.Lock()
+	defer c.unlock()
+
+	size, records := c.replayLog(data, c.clock.Now())
+	if size < int64(len(data)) {
+		c.logger.warn("truncating corrupted append-only log", slog.String("path", path), slog.Int64("size", size))
+		if err := file.Truncate(size); err != nil {
+			file.Close()
+			return err
+		}
+		if _, err := file.Seek(size, io.SeekStart); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	c.aof = &appendOnlyLog{
+		path:     path,
+		policy:   policy,
+		file:     file,
+		size:     size,
+		baseSize: size,
+		done:     make(chan struct{}),
+	}
+	go c.maintainLog(c.aof)
+	c.logger.info("append-only log replayed", slog.String("path", path), slog.Int("records", records))
+	return nil
+}
+
+// replayLog applies the records of the log data to the cache, skipping the entries expired at now.
+// It returns the size of the valid part of the log and the number of records applied.
+// The caller must hold the write lock.
+func (c *MemoryCache) replayLog(data []byte, now time.Time) (int64, int) {
+	offset := len(logMagic) + 1
+	records := 0
+	for offset < len(data) {
+		length, n := binary.Uvarint(data[offset:])
+		if n <= 0 || len(data)-offset-n < 4 || length > uint64(len(data)-offset-n-4) {
+			break
+		}
+		payload := data[offset+n : offset+n+int(length)]
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[offset+n+int(length):]) {
+			break
+		}
+		if !c.applyLogRecord(payload, now) {
+			break
+		}
+		offset += n + int(length) + 4
+		records++
+	}
+	return int64(offset), records
+}
+
+// applyLogRecord applies a single record payload to the cache and reports whether it was valid.
+// The caller must hold the write lock.
+func (c *MemoryCache) applyLogRecord(payload []byte, now time.Time) bool {
+	d := &snapshotDecoder{data: payload}
+	switch d.byte() {
+	case logPut:
+		key := d.string()
+		kind := d.byte()
+		item := &memoryItem{}
+		if expiration := d.varint(); expiration != 0 {
+			item.expiration = time.UnixMilli(expiration)
+		}
+		if tags := d.length(); tags > 0 {
+			item.tags = make([]string, tags)
+			for i := range item.tags {
+				item.tags[i] = d.string()
+			}
+		}
+		item.value = d.value(kind)
+		if d.err != nil {
+			return false
+		}
+		if c.isExpired(item, now) {
+			c.remove(key, EventExpire, "")
+		} else {
+			c.store(key, item)
+		}
+	case logDelete:
+		key := d.string()
+		if d.err != nil {
+			return false
+		}
+		c.remove(key, EventDelete, "")
+	case logFlush:
+		c.clear()
+	default:
+		return false
+	}
+	return d.err == nil && len(d.data) == 0
+}
+
+// maintainLog flushes the append-only log to disk every second if the policy is FsyncEverySec,
+// and compacts it once it grew logRewriteGrowth times larger than after its last compaction.
+func (c *MemoryCache) maintainLog(l *appendOnlyLog) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+		}
+		l.mutex.Lock()
+		file := l.file
+		compact := l.size >= logRewriteMinSize && l.size >= logRewriteGrowth*l.baseSize
+		l.mutex.Unlock()
+		if l.policy == FsyncEverySec && l.dirty.Swap(false) {
+			_ = file.Sync()
+		}
+		if compact {
+			if err := c.CompactLog(); err != nil {
+				c.logger.warn("cannot compact append-only log", slog.String("path", l.path), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// CompactLog rewrites the append-only log so that it only holds the live entries of the cache.
+// The state of the cache is captured under the write lock, then written to a new log while the cache keeps
+// serving requests; the writes made in the meantime are appended to the new log before it replaces the old one.
+// It is called in the background when the log grows, and does nothing if the log is disabled.
+func (c *MemoryCache) CompactLog() error {
+	c.mutex.Lock()
+	l := c.aof
+	if l == nil || l.rewrite != nil {
+		c.mutex.Unlock()
+		return nil
+	}
+	now := c.clock.Now()
+	records := append([]byte(logMagic), logVersion)
+	for key, item := range c.data {
+		if !c.isExpired(item, now) {
+			records = appendLogRecord(records, appendLogPut([]byte{logPut}, key, item))
+		}
+	}
+	l.mutex.Lock()
+	l.rewrite = &bytes.Buffer{}
+	l.mutex.Unlock()
+	c.mutex.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*.tmp")
+	if err == nil {
+		_, err = tmp.Write(records)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	pending := l.rewrite.Bytes()
+	l.rewrite = nil
+	if err == nil && c.aof != l {
+		return discardTemp(tmp, nil)
+	}
+	if err == nil {
+		_, err = tmp.Write(pending)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), l.path)
+	}
+	if err != nil {
+		return discardTemp(tmp, err)
+	}
+	_ = l.file.Close()
+	l.file = tmp
+	l.size = int64(len(records) + len(pending))
+	l.baseSize = l.size
+	c.logger.info("append-only log compacted", slog.String("path", l.path), slog.Int64("size", l.size))
+	return nil
+}
+
+// discardTemp closes and removes the temporary file, if any, and returns err.
+func discardTemp(tmp *os.File, err error) error {
+	if tmp != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Close saves a last periodic snapshot and stops taking them, then stops recording the changes of the memory
+// cache to its append-only log and flushes and closes the log. It does nothing if persistence is disabled.
+func (c *MemoryCache) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	c.background.Wait()
+
+	c.mutex.Lock()
+	l := c.aof
+	c.aof = nil
+	c.mutex.Unlock()
+	if l == nil {
+		return nil
+	}
+
+	close(l.done)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	err := l.writeQueued()
+	if syncErr := l.file.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,164 @@
+package driver_test
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sibeur/go-cache/cachetest"
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestMemoryCache_WithAppendOnlyLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+	clock := cachetest.NewFakeClock(time.Now())
+
+	cache := driver.NewMemoryCache(driver.WithAppendOnlyLog(path, driver.FsyncAlways), driver.WithClock(clock))
+	_ = cache.Set("flushed", "value")
+	_ = cache.Flush()
+	_ = cache.Set("key1", "value1")
+	_ = cache.Set("key2", "value2")
+	_ = cache.Delete("key2")
+	_ = cache.SetWithExpire("expiring", "value", 1)
+	_ = cache.SetWithTags("tagged", "value", 0, "tag1")
+	_ = cache.HSet("hash", map[string]string{"field1": "value1", "field2": "value2"})
+	_ = cache.HDel("hash", "field1")
+	_, _ = cache.RPush("list", "a", "b", "c")
+	_, _ = cache.LPop("list")
+	_ = cache.ZAdd("zset", "player1", 1)
+	_, _ = cache.ZIncrBy("zset", "player1", 2)
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Failed to close the log: %v", err)
+	}
+
+	// The expiring entry expires while the cache is stopped
+	clock.Advance(1100 * time.Millisecond)
+	restored := driver.NewMemoryCache(driver.WithAppendOnlyLog(path, driver.FsyncAlways), driver.WithClock(clock))
+	defer restored.Close()
+
+	for key, expected := range map[string]string{"key1": "value1", "key2": "", "flushed": "", "expiring": "", "tagged": "value"} {
+		if value, _ := restored.Get(key); value != expected {
+			t.Errorf("Retrieved value of %s does not match: expected %q, got %q", key, expected, value)
+		}
+	}
+	if keys, _ := restored.KeysByTag("tag1"); len(keys) != 1 {
+		t.Errorf("Expected tag tag1 to hold 1 key, but got %v", keys)
+	}
+	if fields, _ := restored.HGetAll("hash"); len(fields) != 1 || fields["field2"] != "value2" {
+		t.Errorf("Retrieved hash does not match: got %v", fields)
+	}
+	if values, _ := restored.LRange("list", 0, -1); len(values) != 2 || values[0] != "b" {
+		t.Errorf("Retrieved list does not match: got %v", values)
+	}
+	if score, _, _ := restored.ZScore("zset", "player1"); score != 3 {
+		t.Errorf("Retrieved score does not match: expected %v, got %v", 3, score)
+	}
+}
+
+func TestMemoryCache_WithAppendOnlyLog_TornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	cache := driver.NewMemoryCache(driver.WithAppendOnlyLog(path, driver.FsyncNever))
+	_ = cache.Set("key1", "value1")
+	_ = cache.Close()
+
+	// Simulate a crash in the middle of a write
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	_, _ = f.Write([]byte{42, 1, 2})
+	_ = f.Close()
+
+	restored := driver.NewMemoryCache(driver.WithAppendOnlyLog(path, driver.FsyncNever))
+	if value, _ := restored.Get("key1"); value != "value1" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "value1", value)
+	}
+
+	// The torn tail is truncated, so the records written afterwards are replayed too
+	_ = restored.Set("key2", "value2")
+	_ = restored.Close()
+	reopened := driver.NewMemoryCache(driver.WithAppendOnlyLog(path, driver.FsyncNever))
+	defer reopened.Close()
+	if value, _ := reopened.Get("key2"); value != "value2" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "value2", value)
+	}
+}
+
+func TestMemoryCache_CompactLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	cache := driver.NewMemoryCache(driver.WithAppendOnlyLog(path, driver.FsyncEverySec))
+	for i := 0; i < 1000; i++ {
+		_ = cache.Set("counter", string(rune('a'+i%26)))
+	}
+	before, _ := os.Stat(path)
+
+	if err := cache.CompactLog(); err != nil {
+		t.Fatalf("Failed to compact the log: %v", err)
+	}
+	_ = cache.Set("key1", "value1")
+	after, _ := os.Stat(path)
+	if after.Size() >= before.Size()/10 {
+		t.Errorf("Expected the log to shrink from %d bytes, but got %d", before.Size(), after.Size())
+	}
+	_ = cache.Close()
+
+	restored := driver.NewMemoryCache(driver.WithAppendOnlyLog(path, driver.FsyncEverySec))
+	defer restored.Close()
+	if value, _ := restored.Get("counter"); value != string(rune('a'+999%26)) {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", string(rune('a'+999%26)), value)
+	}
+	if value, _ := restored.Get("key1"); value != "value1" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "value1", value)
+	}
+}
+
+func TestMemoryCache_WithAppendOnlyLog_ConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	// Concurrent writers share the writes to the log, which must keep every change in order
+	cache := driver.NewMemoryCache(driver.WithAppendOnlyLog(path, driver.FsyncAlways))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_ = cache.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", j))
+			}
+		}(i)
+	}
+	wg.Wait()
+	_ = cache.Close()
+
+	restored := driver.NewMemoryCache(driver.WithAppendOnlyLog(path, driver.FsyncAlways))
+	defer restored.Close()
+	for i := 0; i < 8; i++ {
+		if value, _ := restored.Get(fmt.Sprintf("key%d", i)); value != "value49" {
+			t.Errorf("Retrieved value of key%d does not match: expected %s, got %s", i, "value49", value)
+		}
+	}
+}
+
+func TestMemoryCache_WithAppendOnlyLog_NotALog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+	_ = os.WriteFile(path, []byte("not a log"), 0o644)
+
+	// The file is reported and left untouched
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	cache := driver.NewMemoryCache(driver.WithAppendOnlyLog(path, driver.FsyncAlways), driver.WithLogger(logger))
+	_ = cache.Set("key1", "value1")
+	_ = cache.Close()
+
+	if !strings.Contains(buf.String(), driver.ErrLogFormat.Error()) {
+		t.Errorf("Expected ErrLogFormat to be logged, but got %s", buf.String())
+	}
+	if data, _ := os.ReadFile(path); string(data) != "not a log" {
+		t.Errorf("Expected the file to be left untouched, but got %q", data)
+	}
+}
@@ -0,0 +1,117 @@
+package driver_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sibeur/go-cache/driver"
+)
+
+// eventRecorder collects the events received by a handler.
+type eventRecorder struct {
+	mutex  sync.Mutex
+	events []driver.Event
+}
+
+func (r *eventRecorder) handle(event driver.Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) received() []driver.Event {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]driver.Event(nil), r.events...)
+}
+
+func TestMemoryCache_Events(t *testing.T) {
+	cache := driver.NewMemoryCache()
+	recorder := &eventRecorder{}
+	cache.OnSet(recorder.handle)
+	cache.OnDelete(recorder.handle)
+	cache.OnEvict(recorder.handle)
+
+	_ = cache.Set("key1", "value1")
+	_ = cache.Delete("key1")
+	_ = cache.SetWithTags("key2", "value2", 0, "tag")
+	_, _ = cache.InvalidateTags("tag")
+	_ = cache.Set("key3", "value3")
+	_ = cache.Flush()
+
+	expected := []driver.Event{
+		{Type: driver.EventSet, Key: "key1", Value: "value1"},
+		{Type: driver.EventDelete, Key: "key1", Value: "value1"},
+		{Type: driver.EventSet, Key: "key2", Value: "value2"},
+		{Type: driver.EventEvict, Key: "key2", Value: "value2", Reason: driver.EvictReasonInvalidated},
+		{Type: driver.EventSet, Key: "key3", Value: "value3"},
+		{Type: driver.EventEvict, Key: "key3", Value: "value3", Reason: driver.EvictReasonFlush},
+	}
+	events := recorder.received()
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, but got %v", len(expected), events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("Event %d does not match: expected %+v, got %+v", i, expected[i], events[i])
+		}
+	}
+}
+
+func TestMemoryCache_OnExpire(t *testing.T) {
+	cache := driver.NewMemoryCache()
+	recorder := &eventRecorder{}
+	cache.OnExpire(recorder.handle)
+
+	_ = cache.SetWithExpire("key1", "value1", 1)
+
+	// The cleanup routine runs every second
+	deadline := time.Now().Add(3 * time.Second)
+	for len(recorder.received()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	events := recorder.received()
+	if len(events) != 1 || events[0].Key != "key1" || events[0].Value != "value1" {
+		t.Errorf("Expected an expire event for key1, but got %v", events)
+	}
+}
+
+func TestMemoryCache_Events_HandlerCallsCache(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	// Handlers run outside the lock, so writing back into the cache must not deadlock
+	cache.OnDelete(func(event driver.Event) {
+		_ = cache.Set("deleted:"+event.Key, event.Value)
+	})
+
+	_ = cache.Set("key1", "value1")
+	_ = cache.Delete("key1")
+
+	if value, _ := cache.Get("deleted:key1"); value != "value1" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "value1", value)
+	}
+}
+
+func TestMemoryCache_Events_RegisterWhileWriting(t *testing.T) {
+	cache := driver.NewMemoryCache()
+	defer cache.Close()
+	cache.OnSet(func(driver.Event) {})
+
+	// Registering handlers while other goroutines write must not race with the dispatch (run with -race)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = cache.Set("key1", "value1")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			cache.OnSet(func(driver.Event) {})
+		}
+	}()
+	wg.Wait()
+}
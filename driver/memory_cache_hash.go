@@ -0,0 +1,156 @@
+package driver
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/sibeur/go-cache/common"
+)
+
+// HGet retrieves the value of a field of the hash stored under the given key.
+// If the key or the field does not exist, it returns an empty string and ErrMiss.
+// It returns ErrWrongType if the key holds a value that is not a hash.
+// If the cache is unavailable, it logs an error message and returns an empty string.
+func (c *MemoryCache) HGet(key string, field string) (string, error) {
+	defer c.stats.observe(common.OpGet, time.Now())
+	if !c.isAvailable.Load() {
+		return "", c.degraded.unavailableRead(common.OpGet)
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	hash, err := c.getHash(key)
+	if err != nil {
+		return "", c.stats.failed(err)
+	}
+	value, ok := hash[field]
+	c.stats.read(ok)
+	if !ok {
+		return "", ErrMiss
+	}
+	c.logger.operation(common.OpGet, key, slog.String("field", field))
+	return value, nil
+}
+
+// HGetAll retrieves every field of the hash stored under the given key.
+// If the key does not exist, it returns an empty map and no error.
+// It returns ErrWrongType if the key holds a value that is not a hash.
+// If the cache is unavailable, it logs an error message and returns an empty map.
+func (c *MemoryCache) HGetAll(key string) (map[string]string, error) {
+	defer c.stats.observe(common.OpGet, time.Now())
+	if !c.isAvailable.Load() {
+		return map[string]string{}, c.degraded.unavailable(common.OpGet)
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	hash, err := c.getHash(key)
+	if err := c.stats.lookup(hash != nil, err); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string, len(hash))
+	for field, value := range hash {
+		fields[field] = value
+	}
+	c.logger.operation(common.OpGet, key)
+	return fields, nil
+}
+
+// HSet sets the given fields of the hash stored under the given key, creating the hash if needed.
+// The expiration of an existing hash is kept.
+// It returns ErrWrongType if the key holds a value that is not a hash.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (c *MemoryCache) HSet(key string, fields map[string]string) error {
+	defer c.stats.observe(common.OpSet, time.Now())
+	if !c.isAvailable.Load() {
+		return c.degraded.unavailable(common.OpSet)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	if err := c.stats.wrote(c.setHash(key, fields, nil)); err != nil {
+		return err
+	}
+	c.logger.operation(common.OpSet, key)
+	return nil
+}
+
+// HSetWithExpire sets the given fields of the hash stored under the given key, creating the hash if needed,
+// and sets the TTL (in seconds) of the whole hash. A TTL of zero removes the expiration of the hash.
+// It returns ErrWrongType if the key holds a value that is not a hash.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (c *MemoryCache) HSetWithExpire(key string, fields map[string]string, ttl uint64) error {
+	defer c.stats.observe(common.OpSet, time.Now())
+	if !c.isAvailable.Load() {
+		return c.degraded.unavailable(common.OpSet)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	expiration := expiresAt(c.clock.Now(), c.jitter.apply(ttl))
+	if err := c.stats.wrote(c.setHash(key, fields, &expiration)); err != nil {
+		return err
+	}
+	c.logger.operation(common.OpSet, key, slog.Uint64("ttl", ttl))
+	return nil
+}
+
+// HDel removes the given fields from the hash stored under the given key.
+// The hash is deleted once its last field is removed.
+// It returns ErrWrongType if the key holds a value that is not a hash.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (c *MemoryCache) HDel(key string, fields ...string) error {
+	defer c.stats.observe(common.OpDelete, time.Now())
+	if !c.isAvailable.Load() {
+		return c.degraded.unavailable(common.OpDelete)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	hash, err := c.getHash(key)
+	if err != nil || hash == nil {
+		return c.stats.failed(err)
+	}
+	var removed int64
+	for _, field := range fields {
+		if _, ok := hash[field]; ok {
+			delete(hash, field)
+			removed++
+		}
+	}
+	_ = c.stats.removed(removed, nil)
+	c.touch(key)
+	if len(hash) == 0 {
+		c.remove(key, EventDelete, "")
+	}
+	c.logger.operation(common.OpDelete, key, slog.Any("fields", fields))
+	return nil
+}
+
+// getHash returns the live hash stored under the given key, or nil if there is none.
+// The caller must hold the lock.
+func (c *MemoryCache) getHash(key string) (map[string]string, error) {
+	return lookupValue[map[string]string](c, key)
+}
+
+// setHash merges the fields into the hash stored under the given key, creating it if needed.
+// A nil expiration keeps the expiration of an existing hash.
+// The caller must hold the write lock.
+func (c *MemoryCache) setHash(key string, fields map[string]string, expiration *time.Time) error {
+	hash, err := c.getHash(key)
+	if err != nil {
+		return err
+	}
+	if hash == nil {
+		hash = make(map[string]string, len(fields))
+		c.store(key, &memoryItem{value: hash})
+	}
+	for field, value := range fields {
+		hash[field] = value
+	}
+	if expiration != nil {
+		c.data[key].expiration = *expiration
+	}
+	c.touch(key)
+	return nil
+}
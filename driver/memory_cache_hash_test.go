@@ -0,0 +1,105 @@
+package driver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sibeur/go-cache/cachetest"
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestMemoryCache_HSet_HGet(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	// Store a user profile as a single record
+	err := cache.HSet("user:1", map[string]string{"name": "alice", "email": "alice@example.com"})
+	if err != nil {
+		t.Errorf("Failed to set hash: %v", err)
+	}
+
+	value, err := cache.HGet("user:1", "name")
+	if err != nil {
+		t.Errorf("Failed to retrieve field: %v", err)
+	}
+	if value != "alice" {
+		t.Errorf("Retrieved field does not match: expected %s, got %s", "alice", value)
+	}
+
+	// Update a single field and verify the other one is kept
+	_ = cache.HSet("user:1", map[string]string{"name": "bob"})
+	fields, err := cache.HGetAll("user:1")
+	if err != nil {
+		t.Errorf("Failed to retrieve hash: %v", err)
+	}
+	if len(fields) != 2 || fields["name"] != "bob" || fields["email"] != "alice@example.com" {
+		t.Errorf("Retrieved hash does not match: got %v", fields)
+	}
+}
+
+func TestMemoryCache_HDel(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	_ = cache.HSet("user:1", map[string]string{"name": "alice", "email": "alice@example.com"})
+
+	// Delete a field
+	err := cache.HDel("user:1", "email")
+	if err != nil {
+		t.Errorf("Failed to delete field: %v", err)
+	}
+	if value, _ := cache.HGet("user:1", "email"); value != "" {
+		t.Errorf("Field is not deleted: expected empty string, got %s", value)
+	}
+
+	// Deleting the last field must delete the record
+	_ = cache.HDel("user:1", "name")
+	fields, _ := cache.HGetAll("user:1")
+	if len(fields) != 0 {
+		t.Errorf("Expected empty hash, but got %v", fields)
+	}
+}
+
+func TestMemoryCache_HSetWithExpire(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Now())
+	cache := driver.NewMemoryCache(driver.WithClock(clock))
+
+	err := cache.HSetWithExpire("user:1", map[string]string{"name": "alice"}, 1)
+	if err != nil {
+		t.Errorf("Failed to set hash with expiry: %v", err)
+	}
+
+	// Adding a field must keep the TTL of the whole record
+	_ = cache.HSet("user:1", map[string]string{"email": "alice@example.com"})
+
+	// Let the record expire
+	clock.Advance(2 * time.Second)
+
+	fields, err := cache.HGetAll("user:1")
+	if err != nil {
+		t.Errorf("Failed to retrieve hash: %v", err)
+	}
+	if len(fields) != 0 {
+		t.Errorf("Expected hash to be empty, but got %v", fields)
+	}
+}
+
+func TestMemoryCache_HSet_WrongType(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	_ = cache.Set("key1", "value1")
+
+	// Hash operations on a string value must fail
+	err := cache.HSet("key1", map[string]string{"field": "value"})
+	if err != driver.ErrWrongType {
+		t.Errorf("Expected error %v, but got %v", driver.ErrWrongType, err)
+	}
+	_, err = cache.HGet("key1", "field")
+	if err != driver.ErrWrongType {
+		t.Errorf("Expected error %v, but got %v", driver.ErrWrongType, err)
+	}
+
+	// Reading a hash as a string fails the same way, like the Redis driver
+	_ = cache.HSet("hash1", map[string]string{"field": "value"})
+	if _, err := cache.Get("hash1"); err != driver.ErrWrongType {
+		t.Errorf("Expected error %v, but got %v", driver.ErrWrongType, err)
+	}
+}
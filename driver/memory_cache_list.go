@@ -0,0 +1,192 @@
+package driver
+
+import (
+	"time"
+
+	"github.com/sibeur/go-cache/common"
+)
+
+// memoryList is the value stored for a list in the MemoryCache.
+type memoryList struct {
+	values []string // The elements of the list, from head to tail.
+}
+
+// LPush inserts the values at the head of the list stored under the given key, creating the list if needed.
+// The values are inserted one after the other, so the last one ends up first, like the Redis LPUSH command.
+// It returns the length of the list after the push, or ErrWrongType if the key holds a value that is not a list.
+// It returns ErrNoValues, without creating the list, if no values are given.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) LPush(key string, values ...string) (int64, error) {
+	defer c.stats.observe(common.OpSet, time.Now())
+	if len(values) == 0 {
+		return 0, ErrNoValues
+	}
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpSet)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	list, err := c.getOrCreateList(key)
+	if err := c.stats.wrote(err); err != nil {
+		return 0, err
+	}
+	head := make([]string, len(values), len(values)+len(list.values))
+	for i, value := range values {
+		head[len(values)-1-i] = value
+	}
+	list.values = append(head, list.values...)
+	c.touch(key)
+	c.logger.operation(common.OpSet, key)
+	return int64(len(list.values)), nil
+}
+
+// RPush appends the values at the tail of the list stored under the given key, creating the list if needed.
+// It returns the length of the list after the push, or ErrWrongType if the key holds a value that is not a list.
+// It returns ErrNoValues, without creating the list, if no values are given.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) RPush(key string, values ...string) (int64, error) {
+	defer c.stats.observe(common.OpSet, time.Now())
+	if len(values) == 0 {
+		return 0, ErrNoValues
+	}
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpSet)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	list, err := c.getOrCreateList(key)
+	if err := c.stats.wrote(err); err != nil {
+		return 0, err
+	}
+	list.values = append(list.values, values...)
+	c.touch(key)
+	c.logger.operation(common.OpSet, key)
+	return int64(len(list.values)), nil
+}
+
+// LPop removes and returns the first element of the list stored under the given key.
+// If the list does not exist, it returns an empty string and ErrMiss. The list is deleted once it is empty.
+// It returns ErrWrongType if the key holds a value that is not a list.
+// If the cache is unavailable, it logs an error message and returns an empty string.
+func (c *MemoryCache) LPop(key string) (string, error) {
+	defer c.stats.observe(common.OpDelete, time.Now())
+	if !c.isAvailable.Load() {
+		return "", c.degraded.unavailable(common.OpDelete)
+	}
+	return c.pop(key, true)
+}
+
+// RPop removes and returns the last element of the list stored under the given key.
+// If the list does not exist, it returns an empty string and ErrMiss. The list is deleted once it is empty.
+// It returns ErrWrongType if the key holds a value that is not a list.
+// If the cache is unavailable, it logs an error message and returns an empty string.
+func (c *MemoryCache) RPop(key string) (string, error) {
+	defer c.stats.observe(common.OpDelete, time.Now())
+	if !c.isAvailable.Load() {
+		return "", c.degraded.unavailable(common.OpDelete)
+	}
+	return c.pop(key, false)
+}
+
+// LRange returns the elements of the list stored under the given key between the start and stop indexes, inclusive.
+// Negative indexes count from the tail of the list, so LRange(key, 0, -1) returns the whole list.
+// It returns ErrWrongType if the key holds a value that is not a list.
+// If the cache is unavailable, it logs an error message and returns an empty slice.
+func (c *MemoryCache) LRange(key string, start int64, stop int64) ([]string, error) {
+	defer c.stats.observe(common.OpGet, time.Now())
+	if !c.isAvailable.Load() {
+		return []string{}, c.degraded.unavailable(common.OpGet)
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	list, err := lookupValue[*memoryList](c, key)
+	if err := c.stats.lookup(list != nil, err); err != nil || list == nil {
+		return []string{}, err
+	}
+	lo, hi := normalizeRange(start, stop, len(list.values))
+	values := make([]string, hi-lo)
+	copy(values, list.values[lo:hi])
+	c.logger.operation(common.OpGet, key)
+	return values, nil
+}
+
+// LLen returns the length of the list stored under the given key, or zero if it does not exist.
+// It returns ErrWrongType if the key holds a value that is not a list.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) LLen(key string) (int64, error) {
+	defer c.stats.observe(common.OpGet, time.Now())
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpGet)
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	list, err := lookupValue[*memoryList](c, key)
+	if err := c.stats.lookup(list != nil, err); err != nil || list == nil {
+		return 0, err
+	}
+	return int64(len(list.values)), nil
+}
+
+// pop removes and returns the first or the last element of the list stored under the given key.
+func (c *MemoryCache) pop(key string, head bool) (string, error) {
+	c.mutex.Lock()
+	defer c.unlock()
+
+	list, err := lookupValue[*memoryList](c, key)
+	if err != nil {
+		return "", c.stats.failed(err)
+	}
+	if list == nil {
+		return "", ErrMiss
+	}
+	_ = c.stats.removed(1, nil)
+	var value string
+	if head {
+		value, list.values = list.values[0], list.values[1:]
+	} else {
+		last := len(list.values) - 1
+		value, list.values = list.values[last], list.values[:last]
+	}
+	c.touch(key)
+	if len(list.values) == 0 {
+		c.remove(key, EventDelete, "")
+	}
+	c.logger.operation(common.OpDelete, key)
+	return value, nil
+}
+
+// getOrCreateList returns the live list stored under the given key, storing an empty one if there is none.
+// The caller must hold the write lock.
+func (c *MemoryCache) getOrCreateList(key string) (*memoryList, error) {
+	list, err := lookupValue[*memoryList](c, key)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = &memoryList{}
+		c.store(key, &memoryItem{value: list})
+	}
+	return list, nil
+}
+
+// normalizeRange converts inclusive start and stop indexes, which may be negative to count from the end,
+// into the half-open bounds of a slice of the given length, following the Redis LRANGE and ZRANGE rules.
+func normalizeRange(start int64, stop int64, length int) (int, int) {
+	n := int64(length)
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	start = max(start, 0)
+	stop = min(stop, n-1)
+	if start > stop {
+		return 0, 0
+	}
+	return int(start), int(stop + 1)
+}
@@ -0,0 +1,83 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestMemoryCache_Push_Range(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	// Push on both ends of the list
+	_, _ = cache.RPush("activity", "b", "c")
+	length, err := cache.LPush("activity", "a", "z")
+	if err != nil {
+		t.Errorf("Failed to push values: %v", err)
+	}
+	if length != 4 {
+		t.Errorf("Expected length 4, but got %d", length)
+	}
+
+	// The values pushed at the head end up in reverse order
+	values, err := cache.LRange("activity", 0, -1)
+	if err != nil {
+		t.Errorf("Failed to retrieve range: %v", err)
+	}
+	expected := []string{"z", "a", "b", "c"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Retrieved range does not match: expected %v, got %v", expected, values)
+	}
+
+	// Negative indexes count from the tail
+	values, _ = cache.LRange("activity", -2, -1)
+	if !reflect.DeepEqual(values, []string{"b", "c"}) {
+		t.Errorf("Retrieved range does not match: expected %v, got %v", []string{"b", "c"}, values)
+	}
+}
+
+func TestMemoryCache_Pop(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	_, _ = cache.RPush("queue", "first", "second")
+
+	value, err := cache.LPop("queue")
+	if err != nil {
+		t.Errorf("Failed to pop value: %v", err)
+	}
+	if value != "first" {
+		t.Errorf("Popped value does not match: expected %s, got %s", "first", value)
+	}
+
+	value, _ = cache.RPop("queue")
+	if value != "second" {
+		t.Errorf("Popped value does not match: expected %s, got %s", "second", value)
+	}
+
+	// Popping from an empty list is a miss
+	value, err = cache.LPop("queue")
+	if value != "" || !errors.Is(err, driver.ErrMiss) {
+		t.Errorf("Expected empty value and ErrMiss, but got %q and %v", value, err)
+	}
+	if length, _ := cache.LLen("queue"); length != 0 {
+		t.Errorf("Expected length 0, but got %d", length)
+	}
+}
+
+func TestMemoryCache_Push_NoValues(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	// Like Redis, pushing nothing is an error and creates no list
+	if _, err := cache.LPush("activity"); !errors.Is(err, driver.ErrNoValues) {
+		t.Errorf("Expected ErrNoValues, but got %v", err)
+	}
+	if _, err := cache.RPush("activity"); !errors.Is(err, driver.ErrNoValues) {
+		t.Errorf("Expected ErrNoValues, but got %v", err)
+	}
+	if n, _ := cache.Len(context.Background()); n != 0 {
+		t.Errorf("Expected no key, but got %d", n)
+	}
+}
@@ -0,0 +1,138 @@
+package driver
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sibeur/go-cache/common"
+)
+
+// SAdd adds the members to the set stored under the given key, creating the set if needed.
+// It returns the number of members that were not already in the set,
+// or ErrWrongType if the key holds a value that is not a set. It returns ErrNoValues, without creating the set,
+// if no members are given.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) SAdd(key string, members ...string) (int64, error) {
+	defer c.stats.observe(common.OpSet, time.Now())
+	if len(members) == 0 {
+		return 0, ErrNoValues
+	}
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpSet)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	set, err := lookupValue[map[string]struct{}](c, key)
+	if err := c.stats.wrote(err); err != nil {
+		return 0, err
+	}
+	if set == nil {
+		set = make(map[string]struct{}, len(members))
+		c.store(key, &memoryItem{value: set})
+	}
+	var added int64
+	for _, member := range members {
+		if _, ok := set[member]; !ok {
+			set[member] = struct{}{}
+			added++
+		}
+	}
+	c.touch(key)
+	c.logger.operation(common.OpSet, key)
+	return added, nil
+}
+
+// SRem removes the members from the set stored under the given key and returns how many were removed.
+// The set is deleted once it is empty. It returns ErrWrongType if the key holds a value that is not a set.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) SRem(key string, members ...string) (int64, error) {
+	defer c.stats.observe(common.OpDelete, time.Now())
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpDelete)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	set, err := lookupValue[map[string]struct{}](c, key)
+	if err != nil || set == nil {
+		return 0, c.stats.failed(err)
+	}
+	var removed int64
+	for _, member := range members {
+		if _, ok := set[member]; ok {
+			delete(set, member)
+			removed++
+		}
+	}
+	_ = c.stats.removed(removed, nil)
+	c.touch(key)
+	if len(set) == 0 {
+		c.remove(key, EventDelete, "")
+	}
+	c.logger.operation(common.OpDelete, key)
+	return removed, nil
+}
+
+// SMembers returns the members of the set stored under the given key, sorted lexicographically.
+// Redis returns set members in no particular order, so callers must not rely on the order.
+// It returns ErrWrongType if the key holds a value that is not a set.
+// If the cache is unavailable, it logs an error message and returns an empty slice.
+func (c *MemoryCache) SMembers(key string) ([]string, error) {
+	defer c.stats.observe(common.OpGet, time.Now())
+	if !c.isAvailable.Load() {
+		return []string{}, c.degraded.unavailable(common.OpGet)
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	set, err := lookupValue[map[string]struct{}](c, key)
+	if err := c.stats.lookup(set != nil, err); err != nil {
+		return nil, err
+	}
+	members := make([]string, 0, len(set))
+	for member := range set {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	c.logger.operation(common.OpGet, key)
+	return members, nil
+}
+
+// SIsMember reports whether the member belongs to the set stored under the given key.
+// It returns ErrWrongType if the key holds a value that is not a set.
+// If the cache is unavailable, it logs an error message and returns false.
+func (c *MemoryCache) SIsMember(key string, member string) (bool, error) {
+	defer c.stats.observe(common.OpGet, time.Now())
+	if !c.isAvailable.Load() {
+		return false, c.degraded.unavailable(common.OpGet)
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	set, err := lookupValue[map[string]struct{}](c, key)
+	if err != nil {
+		return false, c.stats.failed(err)
+	}
+	_, ok := set[member]
+	c.stats.read(ok)
+	return ok, nil
+}
+
+// SCard returns the number of members of the set stored under the given key, or zero if it does not exist.
+// It returns ErrWrongType if the key holds a value that is not a set.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) SCard(key string) (int64, error) {
+	defer c.stats.observe(common.OpGet, time.Now())
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpGet)
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	set, err := lookupValue[map[string]struct{}](c, key)
+	if err := c.stats.lookup(set != nil, err); err != nil {
+		return 0, err
+	}
+	return int64(len(set)), nil
+}
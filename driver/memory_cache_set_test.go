@@ -0,0 +1,49 @@
+package driver_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestMemoryCache_SAdd_SMembers(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	added, err := cache.SAdd("visitors", "bob", "alice", "bob")
+	if err != nil {
+		t.Errorf("Failed to add members: %v", err)
+	}
+	if added != 2 {
+		t.Errorf("Expected 2 members to be added, but got %d", added)
+	}
+
+	members, err := cache.SMembers("visitors")
+	if err != nil {
+		t.Errorf("Failed to retrieve members: %v", err)
+	}
+	if !reflect.DeepEqual(members, []string{"alice", "bob"}) {
+		t.Errorf("Retrieved members do not match: expected %v, got %v", []string{"alice", "bob"}, members)
+	}
+
+	if ok, _ := cache.SIsMember("visitors", "alice"); !ok {
+		t.Errorf("Expected alice to be a member, but it is not")
+	}
+}
+
+func TestMemoryCache_SRem(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	_, _ = cache.SAdd("visitors", "alice", "bob")
+
+	removed, err := cache.SRem("visitors", "alice", "carol")
+	if err != nil {
+		t.Errorf("Failed to remove members: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 member to be removed, but got %d", removed)
+	}
+	if count, _ := cache.SCard("visitors"); count != 1 {
+		t.Errorf("Expected 1 member, but got %d", count)
+	}
+}
@@ -0,0 +1,387 @@
+package driver
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// A snapshot starts with the snapshotMagic bytes, the format version byte and the moment it was taken,
+// in Unix milliseconds as a varint. The body follows: the number of entries as a uvarint, then every entry.
+// An entry is its key, its kind, its remaining TTL in milliseconds as a uvarint (zero if it never expires),
+// its tags and its value. A snapshot ends with the big-endian CRC-32 (IEEE) of its body.
+//
+// Strings are written as their uvarint length followed by their bytes, and collections as their uvarint
+// length followed by their elements. Sorted set scores are written as the big-endian bits of the float64.
+const (
+	snapshotMagic   = "GCSN" // snapshotMagic identifies a MemoryCache snapshot.
+	snapshotVersion = 1      // snapshotVersion is the version of the format written by SaveTo.
+)
+
+// Kinds of the values of a snapshot entry.
+const (
+	snapshotString byte = iota + 1
+	snapshotHash
+	snapshotList
+	snapshotSet
+	snapshotZSet
+)
+
+// snapshotEntry is an entry read from a snapshot.
+type snapshotEntry struct {
+	key  string
+	item *memoryItem
+}
+
+// SaveTo writes a snapshot of the live entries of the memory cache, with their remaining TTL and tags, to w.
+// The snapshot is encoded under a read lock, then written to w once the lock is released.
+func (c *MemoryCache) SaveTo(w io.Writer) error {
+	c.mutex.RLock()
+	now := c.clock.Now()
+	body := c.encodeSnapshot(now)
+	c.mutex.RUnlock()
+
+	header := append([]byte(snapshotMagic), snapshotVersion)
+	header = binary.AppendVarint(header, now.UnixMilli())
+	trailer := binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(body))
+	for _, part := range [][]byte{header, body, trailer} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFrom reads a snapshot written by SaveTo from r and stores its entries in the memory cache,
+// replacing the entries stored under the same keys. The entries that expired since the snapshot was taken
+// are skipped. Nothing is stored if the snapshot is corrupted: it returns ErrSnapshotFormat, or
+// ErrSnapshotVersion if the snapshot was written by an unsupported version of the format.
+func (c *MemoryCache) LoadFrom(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	entries, err := decodeSnapshot(data, c.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.unlock()
+
+	for _, entry := range entries {
+		c.store(entry.key, entry.item)
+	}
+	c.logger.info("snapshot loaded", slog.Int("entries", len(entries)))
+	return nil
+}
+
+// SaveFile writes a snapshot of the memory cache to the file at path. The snapshot is written to a temporary
+// file that replaces the previous one once complete, so a crash never leaves a partial snapshot behind.
+func (c *MemoryCache) SaveFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	err = c.SaveTo(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFile reads the snapshot stored in the file at path, see LoadFrom.
+func (c *MemoryCache) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.LoadFrom(bufio.NewReader(f))
+}
+
+// restoreSnapshot loads the snapshot file at path if it exists, then saves the cache to it at every interval
+// and once more when the cache is closed.
+// It is started by NewMemoryCache when the WithSnapshot option is set.
+func (c *MemoryCache) restoreSnapshot(path string, interval time.Duration) {
+	if err := c.LoadFile(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		c.logger.warn("cannot load snapshot", slog.String("path", path), slog.Any("error", err))
+	}
+	if interval <= 0 {
+		return
+	}
+	c.background.Add(1)
+	go func() {
+		defer c.background.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.closed:
+				c.saveSnapshot(path)
+				return
+			case <-ticker.C:
+				c.saveSnapshot(path)
+			}
+		}
+	}()
+}
+
+// saveSnapshot saves the cache to the snapshot file at path, logging the failures.
+func (c *MemoryCache) saveSnapshot(path string) {
+	if err := c.SaveFile(path); err != nil {
+		c.logger.warn("cannot save snapshot", slog.String("path", path), slog.Any("error", err))
+	}
+}
+
+// encodeSnapshot returns the body of a snapshot of the entries live at now.
+// The caller must hold the lock.
+func (c *MemoryCache) encodeSnapshot(now time.Time) []byte {
+	var entries []byte
+	var count uint64
+	for key, item := range c.data {
+		if c.isExpired(item, now) {
+			continue
+		}
+		var ttl uint64
+		if !item.expiration.IsZero() {
+			ttl = uint64(max(item.expiration.Sub(now).Milliseconds(), 1))
+		}
+		entries = appendSnapshotString(entries, key)
+		entries = append(entries, snapshotKind(item.value))
+		entries = binary.AppendUvarint(entries, ttl)
+		entries = binary.AppendUvarint(entries, uint64(len(item.tags)))
+		for _, tag := range item.tags {
+			entries = appendSnapshotString(entries, tag)
+		}
+		entries = appendSnapshotValue(entries, item.value)
+		count++
+	}
+	return append(binary.AppendUvarint(nil, count), entries...)
+}
+
+// snapshotKind returns the snapshot kind of a value stored in the memory cache.
+func snapshotKind(value interface{}) byte {
+	switch value.(type) {
+	case map[string]string:
+		return snapshotHash
+	case *memoryList:
+		return snapshotList
+	case map[string]struct{}:
+		return snapshotSet
+	case map[string]float64:
+		return snapshotZSet
+	default:
+		return snapshotString
+	}
+}
+
+// appendSnapshotValue appends the encoding of a value stored in the memory cache to b.
+func appendSnapshotValue(b []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case map[string]string:
+		b = binary.AppendUvarint(b, uint64(len(v)))
+		for field, value := range v {
+			b = appendSnapshotString(appendSnapshotString(b, field), value)
+		}
+	case *memoryList:
+		b = binary.AppendUvarint(b, uint64(len(v.values)))
+		for _, value := range v.values {
+			b = appendSnapshotString(b, value)
+		}
+	case map[string]struct{}:
+		b = binary.AppendUvarint(b, uint64(len(v)))
+		for member := range v {
+			b = appendSnapshotString(b, member)
+		}
+	case map[string]float64:
+		b = binary.AppendUvarint(b, uint64(len(v)))
+		for member, score := range v {
+			b = binary.BigEndian.AppendUint64(appendSnapshotString(b, member), math.Float64bits(score))
+		}
+	case string:
+		b = appendSnapshotString(b, v)
+	}
+	return b
+}
+
+// appendSnapshotString appends the encoding of s to b.
+func appendSnapshotString(b []byte, s string) []byte {
+	return append(binary.AppendUvarint(b, uint64(len(s))), s...)
+}
+
+// decodeSnapshot decodes a whole snapshot, skipping the entries expired at now.
+func decodeSnapshot(data []byte, now time.Time) ([]snapshotEntry, error) {
+	if len(data) < len(snapshotMagic)+1 || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, ErrSnapshotFormat
+	}
+	if data[len(snapshotMagic)] != snapshotVersion {
+		return nil, ErrSnapshotVersion
+	}
+	d := &snapshotDecoder{data: data[len(snapshotMagic)+1:]}
+	savedAt := time.UnixMilli(d.varint())
+	if d.err != nil || len(d.data) < 4 {
+		return nil, ErrSnapshotFormat
+	}
+	body, checksum := d.data[:len(d.data)-4], d.data[len(d.data)-4:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(checksum) {
+		return nil, ErrSnapshotFormat
+	}
+
+	d.data = body
+	count := d.length()
+	entries := make([]snapshotEntry, 0, count)
+	for i := 0; i < count && d.err == nil; i++ {
+		key := d.string()
+		kind := d.byte()
+		item := &memoryItem{}
+		if ttl := d.uvarint(); ttl > 0 {
+			item.expiration = savedAt.Add(time.Duration(ttl) * time.Millisecond)
+		}
+		if tags := d.length(); tags > 0 {
+			item.tags = make([]string, tags)
+			for j := range item.tags {
+				item.tags[j] = d.string()
+			}
+		}
+		item.value = d.value(kind)
+		if !item.expiration.IsZero() && !item.expiration.After(now) {
+			continue
+		}
+		entries = append(entries, snapshotEntry{key: key, item: item})
+	}
+	if d.err != nil || len(d.data) > 0 {
+		return nil, ErrSnapshotFormat
+	}
+	return entries, nil
+}
+
+// snapshotDecoder reads the fields of a snapshot body. The first error is kept in err,
+// after which every read returns a zero value.
+type snapshotDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *snapshotDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = ErrSnapshotFormat
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *snapshotDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.err = ErrSnapshotFormat
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+// length reads a string or collection length, which cannot exceed the remaining data.
+func (d *snapshotDecoder) length() int {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.err = ErrSnapshotFormat
+		return 0
+	}
+	return int(n)
+}
+
+func (d *snapshotDecoder) byte() byte {
+	if d.err != nil || len(d.data) == 0 {
+		d.err = ErrSnapshotFormat
+		return 0
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b
+}
+
+func (d *snapshotDecoder) string() string {
+	n := d.length()
+	if d.err != nil {
+		return ""
+	}
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+	return s
+}
+
+func (d *snapshotDecoder) float64() float64 {
+	if d.err != nil || len(d.data) < 8 {
+		d.err = ErrSnapshotFormat
+		return 0
+	}
+	v := math.Float64frombits(binary.BigEndian.Uint64(d.data))
+	d.data = d.data[8:]
+	return v
+}
+
+// value reads a value of the given kind.
+func (d *snapshotDecoder) value(kind byte) interface{} {
+	switch kind {
+	case snapshotString:
+		return d.string()
+	case snapshotHash:
+		hash := make(map[string]string)
+		for n := d.length(); n > 0 && d.err == nil; n-- {
+			field := d.string()
+			hash[field] = d.string()
+		}
+		return hash
+	case snapshotList:
+		list := &memoryList{}
+		for n := d.length(); n > 0 && d.err == nil; n-- {
+			list.values = append(list.values, d.string())
+		}
+		return list
+	case snapshotSet:
+		set := make(map[string]struct{})
+		for n := d.length(); n > 0 && d.err == nil; n-- {
+			set[d.string()] = struct{}{}
+		}
+		return set
+	case snapshotZSet:
+		zset := make(map[string]float64)
+		for n := d.length(); n > 0 && d.err == nil; n-- {
+			member := d.string()
+			zset[member] = d.float64()
+		}
+		return zset
+	default:
+		d.err = ErrSnapshotFormat
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+package driver_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sibeur/go-cache/cachetest"
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestMemoryCache_SaveTo_LoadFrom(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	// Store a value of every kind
+	_ = cache.Set("string", "value")
+	_ = cache.SetWithTags("tagged", "tagged value", 60, "tag1")
+	_ = cache.HSet("hash", map[string]string{"field1": "value1", "field2": "value2"})
+	_, _ = cache.RPush("list", "a", "b", "c")
+	_, _ = cache.SAdd("set", "x", "y")
+	_ = cache.ZAdd("zset", "player1", 1.5)
+	_ = cache.ZAdd("zset", "player2", -3)
+
+	var buf bytes.Buffer
+	if err := cache.SaveTo(&buf); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+
+	restored := driver.NewMemoryCache()
+	if err := restored.LoadFrom(&buf); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	if value, _ := restored.Get("string"); value != "value" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "value", value)
+	}
+	if keys, _ := restored.KeysByTag("tag1"); len(keys) != 1 || keys[0] != "tagged" {
+		t.Errorf("Expected tag tag1 to hold tagged, but got %v", keys)
+	}
+	if fields, _ := restored.HGetAll("hash"); len(fields) != 2 || fields["field2"] != "value2" {
+		t.Errorf("Retrieved hash does not match: got %v", fields)
+	}
+	if values, _ := restored.LRange("list", 0, -1); len(values) != 3 || values[0] != "a" || values[2] != "c" {
+		t.Errorf("Retrieved list does not match: got %v", values)
+	}
+	if members, _ := restored.SMembers("set"); len(members) != 2 {
+		t.Errorf("Retrieved set does not match: got %v", members)
+	}
+	if score, ok, _ := restored.ZScore("zset", "player2"); !ok || score != -3 {
+		t.Errorf("Retrieved score does not match: expected %v, got %v", -3, score)
+	}
+	if count, _ := restored.Len(context.Background()); count != 6 {
+		t.Errorf("Expected 6 entries, but got %d", count)
+	}
+}
+
+func TestMemoryCache_LoadFrom_SkipsExpired(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Now())
+	cache := driver.NewMemoryCache(driver.WithClock(clock))
+	_ = cache.SetWithExpire("short", "value", 1)
+	_ = cache.SetWithExpire("long", "value", 60)
+
+	var buf bytes.Buffer
+	_ = cache.SaveTo(&buf)
+
+	// The short entry expires while the snapshot is stored
+	clock.Advance(1100 * time.Millisecond)
+
+	restored := driver.NewMemoryCache(driver.WithClock(clock))
+	if err := restored.LoadFrom(&buf); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if value, _ := restored.Get("short"); value != "" {
+		t.Errorf("Expired value was restored: got %s", value)
+	}
+	if value, _ := restored.Get("long"); value != "value" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "value", value)
+	}
+}
+
+func TestMemoryCache_LoadFrom_Invalid(t *testing.T) {
+	cache := driver.NewMemoryCache()
+	_ = cache.Set("key1", "value1")
+
+	var buf bytes.Buffer
+	_ = cache.SaveTo(&buf)
+	snapshot := buf.Bytes()
+
+	// Flip a byte of the body, the checksum must catch it
+	corrupted := append([]byte(nil), snapshot...)
+	corrupted[len(corrupted)-6] ^= 0xff
+	restored := driver.NewMemoryCache()
+	if err := restored.LoadFrom(bytes.NewReader(corrupted)); !errors.Is(err, driver.ErrSnapshotFormat) {
+		t.Errorf("Expected ErrSnapshotFormat, but got %v", err)
+	}
+
+	// A snapshot from a future version must be refused
+	future := append([]byte(nil), snapshot...)
+	future[4] = 99
+	if err := restored.LoadFrom(bytes.NewReader(future)); !errors.Is(err, driver.ErrSnapshotVersion) {
+		t.Errorf("Expected ErrSnapshotVersion, but got %v", err)
+	}
+
+	if count, _ := restored.Len(context.Background()); count != 0 {
+		t.Errorf("Expected no entry to be loaded, but got %d", count)
+	}
+}
+
+func TestMemoryCache_WithSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	cache := driver.NewMemoryCache(driver.WithSnapshot(path, 50*time.Millisecond))
+	_ = cache.Set("key1", "value1")
+
+	// Wait for a periodic snapshot, then simulate a restart
+	time.Sleep(200 * time.Millisecond)
+	restored := driver.NewMemoryCache(driver.WithSnapshot(path, 0))
+
+	if value, _ := restored.Get("key1"); value != "value1" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "value1", value)
+	}
+
+	// Closing the cache takes a last snapshot and stops the periodic ones
+	_ = cache.Set("key2", "value2")
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Failed to close the cache: %v", err)
+	}
+	restored = driver.NewMemoryCache(driver.WithSnapshot(path, 0))
+	if value, _ := restored.Get("key2"); value != "value2" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "value2", value)
+	}
+}
@@ -0,0 +1,98 @@
+package driver
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/sibeur/go-cache/common"
+)
+
+// SetWithTags sets a key-value pair in the memory cache and associates it with the given tags.
+// A TTL (in seconds) of zero stores the entry without expiration.
+// Storing the key again replaces its previous tags.
+// Once the entry is deleted or expires, it is also removed from the tag index.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (c *MemoryCache) SetWithTags(key string, value string, ttl uint64, tags ...string) error {
+	defer c.stats.observe(common.OpSet, time.Now())
+	if !c.isAvailable.Load() {
+		return c.degraded.unavailable(common.OpSet)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	item := &memoryItem{value: value, expiration: expiresAt(c.clock.Now(), c.jitter.apply(ttl)), tags: tags}
+	c.store(key, item)
+	c.stats.sets.Add(1)
+	c.logger.operation(common.OpSet, key, slog.Uint64("ttl", ttl), c.logger.tags(tags))
+	return nil
+}
+
+// InvalidateTags removes every entry associated with at least one of the given tags
+// and returns how many live entries were removed.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) InvalidateTags(tags ...string) (int64, error) {
+	defer c.stats.observe(common.OpInvalidate, time.Now())
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpInvalidate)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+	var removed int64
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if !c.isExpired(c.data[key], now) {
+				removed++
+			}
+			c.remove(key, EventEvict, EvictReasonInvalidated)
+		}
+	}
+	c.stats.deletes.Add(uint64(removed))
+	c.logger.operation(common.OpInvalidate, "", c.logger.tags(tags))
+	return removed, nil
+}
+
+// KeysByTag returns the keys of the live entries associated with the given tag.
+// If the cache is unavailable, it logs an error message and returns no keys.
+func (c *MemoryCache) KeysByTag(tag string) ([]string, error) {
+	if !c.isAvailable.Load() {
+		return nil, c.degraded.unavailable(common.OpScan)
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	now := c.clock.Now()
+	keys := make([]string, 0, len(c.tags[tag]))
+	for key := range c.tags[tag] {
+		if !c.isExpired(c.data[key], now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// tag adds the key to the reverse index of every tag of the item.
+// The caller must hold the write lock.
+func (c *MemoryCache) tag(key string, item *memoryItem) {
+	for _, tag := range item.tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// untag removes the key from the reverse index of every tag of the item,
+// dropping the tags that are left without keys.
+// The caller must hold the write lock.
+func (c *MemoryCache) untag(key string, item *memoryItem) {
+	for _, tag := range item.tags {
+		delete(c.tags[tag], key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package driver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sibeur/go-cache/cachetest"
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestMemoryCache_InvalidateTags(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	// Cache pages of two products under unrelated keys
+	_ = cache.SetWithTags("page:home", "home", 0, "product:17", "product:18")
+	_ = cache.SetWithTags("page:product:17", "product 17", 0, "product:17")
+	_ = cache.SetWithTags("page:product:18", "product 18", 60, "product:18")
+
+	// Invalidate everything touching product 17
+	removed, err := cache.InvalidateTags("product:17")
+	if err != nil {
+		t.Errorf("Failed to invalidate tags: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 keys to be removed, but got %d", removed)
+	}
+
+	// Verify that the pages of product 17 are deleted
+	for _, key := range []string{"page:home", "page:product:17"} {
+		if value, _ := cache.Get(key); value != "" {
+			t.Errorf("Value of %s is not deleted: expected empty string, got %s", key, value)
+		}
+	}
+
+	// Verify that the page of product 18 is kept and the home page left its tag
+	if value, _ := cache.Get("page:product:18"); value != "product 18" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "product 18", value)
+	}
+	keys, _ := cache.KeysByTag("product:18")
+	if len(keys) != 1 || keys[0] != "page:product:18" {
+		t.Errorf("Expected tag product:18 to hold page:product:18 only, but got %v", keys)
+	}
+}
+
+func TestMemoryCache_SetWithTags_ReplacesTags(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	_ = cache.SetWithTags("key1", "value1", 0, "old")
+
+	// Storing the key again must move it to the new tag
+	_ = cache.SetWithTags("key1", "value2", 0, "new")
+
+	if keys, _ := cache.KeysByTag("old"); len(keys) != 0 {
+		t.Errorf("Expected tag old to be empty, but got %v", keys)
+	}
+	if keys, _ := cache.KeysByTag("new"); len(keys) != 1 {
+		t.Errorf("Expected tag new to hold 1 key, but got %v", keys)
+	}
+
+	// Deleting the key must remove it from its tag
+	_ = cache.Delete("key1")
+	if keys, _ := cache.KeysByTag("new"); len(keys) != 0 {
+		t.Errorf("Expected tag new to be empty, but got %v", keys)
+	}
+}
+
+func TestMemoryCache_SetWithTags_Expire(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Now())
+	cache := driver.NewMemoryCache(driver.WithClock(clock))
+
+	err := cache.SetWithTags("key1", "value1", 1, "tag1")
+	if err != nil {
+		t.Errorf("Failed to set value with tags: %v", err)
+	}
+
+	// Let the value expire and be cleaned up
+	advanceCleanup(clock, 2*time.Second)
+
+	// Check that the expired key left the tag
+	keys, err := cache.KeysByTag("tag1")
+	if err != nil {
+		t.Errorf("Failed to get keys by tag: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Expected tag to be empty, but got %v", keys)
+	}
+
+	// Check that invalidating the tag does not count the expired key
+	removed, err := cache.InvalidateTags("tag1")
+	if err != nil {
+		t.Errorf("Failed to invalidate tags: %v", err)
+	}
+	if removed != 0 {
+		t.Errorf("Expected 0 keys to be removed, but got %d", removed)
+	}
+}
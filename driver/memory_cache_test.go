@@ -1,12 +1,24 @@
 package driver_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/sibeur/go-cache/cachetest"
 	"github.com/sibeur/go-cache/driver"
 )
 
+// advanceCleanup moves the fake clock of a memory cache forward by d,
+// then waits for the cleanup routine to remove the entries that expired.
+func advanceCleanup(clock *cachetest.FakeClock, d time.Duration) {
+	// The cleanup routine waits on the clock before and after removing the expired entries
+	clock.BlockUntil(1)
+	clock.Advance(d)
+	clock.BlockUntil(1)
+}
+
 func TestMemoryCache_Set_Get(t *testing.T) {
 	cache := driver.NewMemoryCache()
 
@@ -112,7 +124,8 @@ func TestMemoryCache_Flush(t *testing.T) {
 }
 
 func TestMemoryCache_SetWithExpire(t *testing.T) {
-	cache := driver.NewMemoryCache()
+	clock := cachetest.NewFakeClock(time.Now())
+	cache := driver.NewMemoryCache(driver.WithClock(clock))
 
 	// Set a value with
 	key := "setwithexpirememory"
@@ -122,14 +135,13 @@ func TestMemoryCache_SetWithExpire(t *testing.T) {
 		t.Errorf("Failed to set value with expiry: %v", err)
 	}
 
-	// Wait for the value to expire
-	// Sleep for 2 seconds to ensure the value has expired
-	time.Sleep(2 * time.Second)
+	// Let the value expire
+	clock.Advance(2 * time.Second)
 
 	// Get the value from the cache
 	retrievedValue, err := cache.Get(key)
-	if err != nil {
-		t.Errorf("Failed to retrieve value: %v", err)
+	if !errors.Is(err, driver.ErrMiss) {
+		t.Errorf("Expected a miss, but got %v", err)
 	}
 
 	// Check if the retrieved value is empty
@@ -144,14 +156,13 @@ func TestMemoryCache_SetWithExpire(t *testing.T) {
 		t.Errorf("Failed to set updated value with expiry: %v", err)
 	}
 
-	// Wait for the value to expire
-	// Sleep for 2 seconds to ensure the value has expired
-	time.Sleep(2 * time.Second)
+	// Let the value expire
+	clock.Advance(2 * time.Second)
 
 	// Get the value from the cache
 	retrievedValue, err = cache.Get(key)
-	if err != nil {
-		t.Errorf("Failed to retrieve updated value: %v", err)
+	if !errors.Is(err, driver.ErrMiss) {
+		t.Errorf("Expected a miss, but got %v", err)
 	}
 
 	// Check if the retrieved value is empty
@@ -178,6 +189,37 @@ func TestMemoryCache_IsCacheAvailable(t *testing.T) {
 	}
 }
 
+func TestMemoryCache_Unavailable(t *testing.T) {
+	cache := driver.NewMemoryCache()
+	_ = cache.Set("key1", "value1")
+	_, _ = cache.RPush("list", "a")
+
+	cache.SetCacheAvailable(false)
+
+	// An unavailable cache serves nothing and drops the writes, like the Redis driver
+	if value, err := cache.Get("key1"); value != "" || !errors.Is(err, driver.ErrMiss) {
+		t.Errorf("Expected an empty value and ErrMiss, but got %q and %v", value, err)
+	}
+	if values, _ := cache.LRange("list", 0, -1); len(values) != 0 {
+		t.Errorf("Expected an empty list, but got %v", values)
+	}
+	_ = cache.Set("key2", "value2")
+	_ = cache.Delete("key1")
+	_ = cache.Flush()
+
+	// The values stored before are served again once the cache is available
+	cache.SetCacheAvailable(true)
+	if value, _ := cache.Get("key1"); value != "value1" {
+		t.Errorf("Expected value1, but got %q", value)
+	}
+	if value, _ := cache.Get("key2"); value != "" {
+		t.Errorf("Expected the write made while unavailable to be dropped, but got %q", value)
+	}
+	if length, _ := cache.LLen("list"); length != 1 {
+		t.Errorf("Expected a list of 1 element, but got %d", length)
+	}
+}
+
 func TestMemoryCache_SetCacheAvailable(t *testing.T) {
 	cache := driver.NewMemoryCache()
 
@@ -209,7 +251,8 @@ func TestMemoryCache_GetDriverName(t *testing.T) {
 }
 
 func TestMemoryCache_SetWithExpire_WithLongExpireOptionInNewMemoryCache(t *testing.T) {
-	cache := driver.NewMemoryCache()
+	clock := cachetest.NewFakeClock(time.Now())
+	cache := driver.NewMemoryCache(driver.WithClock(clock))
 
 	// Set a value with
 	key := "setwithexpirememory"
@@ -219,14 +262,13 @@ func TestMemoryCache_SetWithExpire_WithLongExpireOptionInNewMemoryCache(t *testi
 		t.Errorf("Failed to set value with expiry: %v", err)
 	}
 
-	// Wait for the value to expire
-	// Sleep for 2 seconds to ensure the value has expired
-	time.Sleep(4 * time.Second)
+	// Let the value expire and be cleaned up
+	advanceCleanup(clock, 4*time.Second)
 
 	// Get the value from the cache
 	retrievedValue, err := cache.Get(key)
-	if err != nil {
-		t.Errorf("Failed to retrieve value: %v", err)
+	if !errors.Is(err, driver.ErrMiss) {
+		t.Errorf("Expected a miss, but got %v", err)
 	}
 
 	// Check if the retrieved value is empty
@@ -235,3 +277,167 @@ func TestMemoryCache_SetWithExpire_WithLongExpireOptionInNewMemoryCache(t *testi
 	}
 
 }
+
+func TestMemoryCache_Scan(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	// Set values under two prefixes, one of them with an expiry
+	_ = cache.Set("user:1", "alice")
+	_ = cache.Set("user:2", "bob")
+	_ = cache.SetWithExpire("user:3", "carol", 10)
+	_ = cache.Set("order:1", "book")
+
+	keys := map[string]bool{}
+	err := cache.Scan(context.Background(), "user:*", func(key string) bool {
+		keys[key] = true
+		return true
+	})
+	if err != nil {
+		t.Errorf("Failed to scan cache: %v", err)
+	}
+
+	// Only the user keys must be reported, without any internal bookkeeping entries
+	expectedKeys := []string{"user:1", "user:2", "user:3"}
+	if len(keys) != len(expectedKeys) {
+		t.Errorf("Expected %d keys, but got %d: %v", len(expectedKeys), len(keys), keys)
+	}
+	for _, key := range expectedKeys {
+		if !keys[key] {
+			t.Errorf("Expected key %s to be scanned, but it was not", key)
+		}
+	}
+}
+
+func TestMemoryCache_Scan_Pattern(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	for _, key := range []string{"hello", "hallo", "hxllo", "hllo", "heeeello", "h*llo"} {
+		_ = cache.Set(key, "value")
+	}
+
+	testCases := []struct {
+		pattern  string
+		expected int
+	}{
+		{"h?llo", 4},
+		{"h*llo", 6},
+		{"h[ae]llo", 2},
+		{"h[^e]llo", 3},
+		{"h[a-b]llo", 1},
+		{"h\\*llo", 1},
+		{"", 6},
+	}
+
+	for _, tc := range testCases {
+		count := 0
+		err := cache.Scan(context.Background(), tc.pattern, func(key string) bool {
+			count++
+			return true
+		})
+		if err != nil {
+			t.Errorf("Failed to scan cache with pattern %s: %v", tc.pattern, err)
+		}
+		if count != tc.expected {
+			t.Errorf("Expected %d keys for pattern %s, but got %d", tc.expected, tc.pattern, count)
+		}
+	}
+}
+
+func TestMemoryCache_Scan_Stop(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	for _, key := range []string{"key1", "key2", "key3"} {
+		_ = cache.Set(key, "value")
+	}
+
+	// Stop the iteration after the first key
+	count := 0
+	err := cache.Scan(context.Background(), "*", func(key string) bool {
+		count++
+		return false
+	})
+	if err != nil {
+		t.Errorf("Failed to scan cache: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected scan to stop after 1 key, but got %d", count)
+	}
+}
+
+func TestMemoryCache_Len(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	_ = cache.Set("key1", "value1")
+	_ = cache.SetWithExpire("key2", "value2", 10)
+
+	count, err := cache.Len(context.Background())
+	if err != nil {
+		t.Errorf("Failed to count cache entries: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 entries, but got %d", count)
+	}
+
+	// Deleting a key must decrease the count
+	_ = cache.Delete("key2")
+	count, _ = cache.Len(context.Background())
+	if count != 1 {
+		t.Errorf("Expected 1 entry, but got %d", count)
+	}
+}
+
+func TestMemoryCache_DeleteByPattern(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	_ = cache.Set("tenant:42:user", "alice")
+	_ = cache.Set("tenant:42:order", "book")
+	_ = cache.Set("tenant:43:user", "bob")
+
+	// Delete every key of tenant 42
+	removed, err := cache.DeleteByPattern(context.Background(), "tenant:42:*")
+	if err != nil {
+		t.Errorf("Failed to delete by pattern: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 keys to be removed, but got %d", removed)
+	}
+
+	// Verify that only the keys of tenant 42 are deleted
+	if value, _ := cache.Get("tenant:42:user"); value != "" {
+		t.Errorf("Value is not deleted: expected empty string, got %s", value)
+	}
+	if value, _ := cache.Get("tenant:43:user"); value != "bob" {
+		t.Errorf("Retrieved value does not match: expected %s, got %s", "bob", value)
+	}
+}
+
+func TestMemoryCache_DeleteByPrefix(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	_ = cache.Set("tenant:4", "four")
+	_ = cache.Set("tenant:42", "forty-two")
+	_ = cache.Set("tenant:*", "star")
+
+	// The prefix must be matched literally
+	removed, err := cache.DeleteByPrefix(context.Background(), "tenant:*")
+	if err != nil {
+		t.Errorf("Failed to delete by prefix: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 key to be removed, but got %d", removed)
+	}
+
+	removed, err = cache.DeleteByPrefix(context.Background(), "tenant:4")
+	if err != nil {
+		t.Errorf("Failed to delete by prefix: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 keys to be removed, but got %d", removed)
+	}
+
+	// Verify that the cache is empty
+	count, _ := cache.Len(context.Background())
+	if count != 0 {
+		t.Errorf("Expected empty cache, but got %d entries", count)
+	}
+}
@@ -0,0 +1,179 @@
+package driver
+
+import (
+	"time"
+
+	"github.com/sibeur/go-cache/common"
+)
+
+// ZAdd adds the member with the given score to the sorted set stored under the given key,
+// creating the set if needed. The score of an existing member is updated.
+// It returns ErrWrongType if the key holds a value that is not a sorted set.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (c *MemoryCache) ZAdd(key string, member string, score float64) error {
+	defer c.stats.observe(common.OpSet, time.Now())
+	if !c.isAvailable.Load() {
+		return c.degraded.unavailable(common.OpSet)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	zset, err := c.getOrCreateZSet(key)
+	if err := c.stats.wrote(err); err != nil {
+		return err
+	}
+	zset[member] = score
+	c.touch(key)
+	c.logger.operation(common.OpSet, key)
+	return nil
+}
+
+// ZIncrBy increments the score of the member of the sorted set stored under the given key,
+// adding the member with the increment as its score if it does not exist yet. It returns the new score.
+// It returns ErrWrongType if the key holds a value that is not a sorted set.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) ZIncrBy(key string, member string, increment float64) (float64, error) {
+	defer c.stats.observe(common.OpSet, time.Now())
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpSet)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	zset, err := c.getOrCreateZSet(key)
+	if err := c.stats.wrote(err); err != nil {
+		return 0, err
+	}
+	zset[member] += increment
+	c.touch(key)
+	c.logger.operation(common.OpSet, key)
+	return zset[member], nil
+}
+
+// ZScore returns the score of the member of the sorted set stored under the given key.
+// The boolean result is false if the key or the member does not exist.
+// It returns ErrWrongType if the key holds a value that is not a sorted set.
+// If the cache is unavailable, it logs an error message and returns false.
+func (c *MemoryCache) ZScore(key string, member string) (float64, bool, error) {
+	defer c.stats.observe(common.OpGet, time.Now())
+	if !c.isAvailable.Load() {
+		return 0, false, c.degraded.unavailable(common.OpGet)
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	zset, err := lookupValue[map[string]float64](c, key)
+	if err != nil {
+		return 0, false, c.stats.failed(err)
+	}
+	score, ok := zset[member]
+	c.stats.read(ok)
+	return score, ok, nil
+}
+
+// ZRem removes the members from the sorted set stored under the given key and returns how many were removed.
+// The sorted set is deleted once it is empty. It returns ErrWrongType if the key holds a value that is not a sorted set.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) ZRem(key string, members ...string) (int64, error) {
+	defer c.stats.observe(common.OpDelete, time.Now())
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpDelete)
+	}
+	c.mutex.Lock()
+	defer c.unlock()
+
+	zset, err := lookupValue[map[string]float64](c, key)
+	if err != nil || zset == nil {
+		return 0, c.stats.failed(err)
+	}
+	var removed int64
+	for _, member := range members {
+		if _, ok := zset[member]; ok {
+			delete(zset, member)
+			removed++
+		}
+	}
+	_ = c.stats.removed(removed, nil)
+	c.touch(key)
+	if len(zset) == 0 {
+		c.remove(key, EventDelete, "")
+	}
+	c.logger.operation(common.OpDelete, key)
+	return removed, nil
+}
+
+// ZRange returns the members of the sorted set stored under the given key between the start and stop ranks,
+// inclusive, ordered from the lowest to the highest score. Negative ranks count from the highest score.
+// It returns ErrWrongType if the key holds a value that is not a sorted set.
+// If the cache is unavailable, it logs an error message and returns an empty slice.
+func (c *MemoryCache) ZRange(key string, start int64, stop int64) ([]ScoredMember, error) {
+	defer c.stats.observe(common.OpGet, time.Now())
+	if !c.isAvailable.Load() {
+		return []ScoredMember{}, c.degraded.unavailable(common.OpGet)
+	}
+	return c.zrange(key, start, stop, false)
+}
+
+// ZRevRange returns the members of the sorted set stored under the given key between the start and stop ranks,
+// inclusive, ordered from the highest to the lowest score. Negative ranks count from the lowest score.
+// It returns ErrWrongType if the key holds a value that is not a sorted set.
+// If the cache is unavailable, it logs an error message and returns an empty slice.
+func (c *MemoryCache) ZRevRange(key string, start int64, stop int64) ([]ScoredMember, error) {
+	defer c.stats.observe(common.OpGet, time.Now())
+	if !c.isAvailable.Load() {
+		return []ScoredMember{}, c.degraded.unavailable(common.OpGet)
+	}
+	return c.zrange(key, start, stop, true)
+}
+
+// ZCard returns the number of members of the sorted set stored under the given key, or zero if it does not exist.
+// It returns ErrWrongType if the key holds a value that is not a sorted set.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (c *MemoryCache) ZCard(key string) (int64, error) {
+	defer c.stats.observe(common.OpGet, time.Now())
+	if !c.isAvailable.Load() {
+		return 0, c.degraded.unavailable(common.OpGet)
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	zset, err := lookupValue[map[string]float64](c, key)
+	if err := c.stats.lookup(zset != nil, err); err != nil {
+		return 0, err
+	}
+	return int64(len(zset)), nil
+}
+
+// zrange sorts the members of the sorted set stored under the given key and returns the requested ranks.
+// The memory driver keeps sorted sets as plain maps, so every range query sorts the whole set.
+func (c *MemoryCache) zrange(key string, start int64, stop int64, reverse bool) ([]ScoredMember, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	zset, err := lookupValue[map[string]float64](c, key)
+	if err := c.stats.lookup(zset != nil, err); err != nil {
+		return nil, err
+	}
+	members := make([]ScoredMember, 0, len(zset))
+	for member, score := range zset {
+		members = append(members, ScoredMember{Member: member, Score: score})
+	}
+	sortScoredMembers(members, reverse)
+	lo, hi := normalizeRange(start, stop, len(members))
+	c.logger.operation(common.OpGet, key)
+	return members[lo:hi], nil
+}
+
+// getOrCreateZSet returns the live sorted set stored under the given key, storing an empty one if there is none.
+// The caller must hold the write lock.
+func (c *MemoryCache) getOrCreateZSet(key string) (map[string]float64, error) {
+	zset, err := lookupValue[map[string]float64](c, key)
+	if err != nil {
+		return nil, err
+	}
+	if zset == nil {
+		zset = make(map[string]float64)
+		c.store(key, &memoryItem{value: zset})
+	}
+	return zset, nil
+}
@@ -0,0 +1,65 @@
+package driver_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestMemoryCache_Leaderboard(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	_ = cache.ZAdd("leaderboard", "alice", 10)
+	_ = cache.ZAdd("leaderboard", "bob", 20)
+	_ = cache.ZAdd("leaderboard", "carol", 10)
+
+	score, err := cache.ZIncrBy("leaderboard", "alice", 15)
+	if err != nil {
+		t.Errorf("Failed to increment score: %v", err)
+	}
+	if score != 25 {
+		t.Errorf("Expected score 25, but got %v", score)
+	}
+
+	// Top two players, highest score first
+	top, err := cache.ZRevRange("leaderboard", 0, 1)
+	if err != nil {
+		t.Errorf("Failed to retrieve range: %v", err)
+	}
+	expected := []driver.ScoredMember{{Member: "alice", Score: 25}, {Member: "bob", Score: 20}}
+	if !reflect.DeepEqual(top, expected) {
+		t.Errorf("Retrieved range does not match: expected %v, got %v", expected, top)
+	}
+
+	// Lowest score first
+	bottom, _ := cache.ZRange("leaderboard", 0, 0)
+	if len(bottom) != 1 || bottom[0].Member != "carol" {
+		t.Errorf("Expected carol to have the lowest score, but got %v", bottom)
+	}
+}
+
+func TestMemoryCache_ZScore_ZRem(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	_ = cache.ZAdd("leaderboard", "alice", 10)
+
+	score, ok, err := cache.ZScore("leaderboard", "alice")
+	if err != nil || !ok || score != 10 {
+		t.Errorf("Expected score 10, but got %v (found %v, error %v)", score, ok, err)
+	}
+
+	removed, _ := cache.ZRem("leaderboard", "alice")
+	if removed != 1 {
+		t.Errorf("Expected 1 member to be removed, but got %d", removed)
+	}
+
+	// A missing member is reported as not found
+	_, ok, err = cache.ZScore("leaderboard", "alice")
+	if err != nil || ok {
+		t.Errorf("Expected member to be missing, but got found %v and error %v", ok, err)
+	}
+	if count, _ := cache.ZCard("leaderboard"); count != 0 {
+		t.Errorf("Expected 0 members, but got %d", count)
+	}
+}
@@ -0,0 +1,203 @@
+package driver
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// Option configures a cache driver. Options are passed to NewMemoryCache, NewRedisCache, NewDiskCache
+// and NewFailoverCache.
+type Option func(*options)
+
+// options holds the settings shared by the cache drivers.
+type options struct {
+	logger     *slog.Logger // The logger receiving the driver logs.
+	redactKeys bool         // Whether keys are hashed before being logged.
+
+	snapshotPath     string        // The snapshot file of the memory driver, empty to disable snapshots.
+	snapshotInterval time.Duration // The interval between two snapshots of the memory driver.
+
+	logPath  string      // The append-only log of the memory driver, empty to disable the log.
+	logFsync FsyncPolicy // When the append-only log of the memory driver is flushed to disk.
+
+	maxSize int64 // The maximum total size of the files of the disk driver, zero for no limit.
+
+	clock Clock // The clock of the memory driver.
+
+	degradedMode DegradedMode // How the driver behaves while it is unavailable.
+
+	ttlJitter    ttlJitter   // The random delay added to the TTLs.
+	jitterSource rand.Source // The source of the random delays added to the TTLs, nil for the global one.
+	noJitter     bool        // Whether the TTLs are kept as given, whatever the jitter options.
+
+	eventKeyPrefix string // The prefix of the keys whose events the Redis driver raises, empty for every key.
+
+	retry *RetryPolicy // How the Redis driver retries the commands failing with a transient error, nil for no retries.
+
+	healthInterval  time.Duration // The interval between two health checks of Redis, during an outage or for the failover driver.
+	flushOnRecovery bool          // Whether the failover driver flushes its local cache when Redis recovers.
+	keepFallback    bool          // Whether the Redis driver keeps the content of its fallback cache when Redis recovers.
+}
+
+// newOptions applies the given options on top of the defaults.
+func newOptions(opts []Option) *options {
+	o := &options{
+		logger: slog.Default(),
+		clock:  systemClock{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithLogger sets the logger used by the driver. By default the driver logs to slog.Default().
+// Every cache operation is logged at debug level, so it is only emitted when the logger enables that level.
+// A nil logger discards all logs.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		if logger == nil {
+			logger = slog.New(discardHandler{})
+		}
+		o.logger = logger
+	}
+}
+
+// WithRedactedKeys makes the driver log a hash of the keys, patterns and tags instead of their value,
+// for keys and tags that contain personal or secret data.
+func WithRedactedKeys() Option {
+	return func(o *options) {
+		o.redactKeys = true
+	}
+}
+
+// WithSnapshot makes the memory driver load the snapshot file at path when it is created, if it exists,
+// then save a new snapshot to it at every interval. A zero interval only loads the snapshot.
+// The entries that expired while the service was stopped are skipped. It is ignored by the Redis driver.
+func WithSnapshot(path string, interval time.Duration) Option {
+	return func(o *options) {
+		o.snapshotPath = path
+		o.snapshotInterval = interval
+	}
+}
+
+// WithAppendOnlyLog makes the memory driver record every set, delete, expiry and flush to the append-only log
+// at path, and replay the log when it is created so that no acknowledged write is lost across restarts.
+// The fsync policy tells when the log is flushed to disk. The log is compacted in the background as it grows.
+// When combined with WithSnapshot, the snapshot is loaded first and the log replayed over it.
+// Call MemoryCache.Close to flush and close the log. It is ignored by the Redis driver.
+func WithAppendOnlyLog(path string, fsync FsyncPolicy) Option {
+	return func(o *options) {
+		o.logPath = path
+		o.logFsync = fsync
+	}
+}
+
+// WithMaxSize limits the total size in bytes of the files of the disk driver. Once a write exceeds it,
+// the least recently used entries are evicted. A size of zero, the default, sets no limit.
+// It is ignored by the memory and Redis drivers.
+func WithMaxSize(bytes int64) Option {
+	return func(o *options) {
+		o.maxSize = bytes
+	}
+}
+
+// WithClock sets the clock the memory and disk drivers use to expire their entries, so that tests can move time
+// forward instead of sleeping. By default, or if the clock is nil, the system clock is used.
+// It is ignored by the Redis driver. The TTL jitter draws its delays from its own source, see WithTTLJitterSource,
+// and WithoutTTLJitter turns it off.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		if clock == nil {
+			clock = systemClock{}
+		}
+		o.clock = clock
+	}
+}
+
+// WithDegradedMode sets how the driver behaves while it is unavailable: FailOpen, the default, FailClosed
+// or FallbackToMemory. The mode applies to every operation of the driver.
+func WithDegradedMode(mode DegradedMode) Option {
+	return func(o *options) {
+		o.degradedMode = mode
+	}
+}
+
+// WithHealthCheck sets the interval at which the failover driver pings Redis to detect an outage and its recovery,
+// and at which the Redis driver falling back to memory pings it during an outage to detect its recovery.
+// It defaults to one second. It is ignored by the other drivers.
+func WithHealthCheck(interval time.Duration) Option {
+	return func(o *options) {
+		o.healthInterval = interval
+	}
+}
+
+// WithFlushOnRecovery makes the failover driver flush its local cache when it switches back to Redis,
+// so that a later outage never serves values older than those written to Redis in the meantime.
+// Whether or not it is set, the keys written or deleted in the local cache during an outage, and the flushes,
+// pattern, prefix and tag deletions, are replayed on Redis as deletions when it recovers, so that Redis never
+// serves the values they replaced. It is ignored by the other drivers, the Redis driver falling back to memory
+// always flushing its local cache on recovery.
+func WithFlushOnRecovery() Option {
+	return func(o *options) {
+		o.flushOnRecovery = true
+	}
+}
+
+// WithRetry makes the Redis driver retry the commands failing with a transient error, such as a timeout,
+// according to the policy. Only the idempotent commands are retried by the driver, since a timeout may hide the
+// success of the first attempt: LPush, RPush, LPop, RPop and ZIncrBy are not. The client is left untouched and
+// keeps retrying every command on its own within each attempt of the policy, the non-idempotent ones included,
+// without the backoff of the policy (MaxRetries in redis.Options, 3 by default). Set MaxRetries to -1 so that
+// only the policy retries and the non-idempotent commands are sent once. It is ignored by the other drivers.
+func WithRetry(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = &policy
+	}
+}
+
+// WithEventKeyPrefix makes the Redis driver raise only the events of the keys starting with prefix, both to
+// Subscribe and to the handlers registered with OnSet, OnDelete, OnExpire and OnEvict. The keyevent notifications
+// cover the whole database of the client, so without it the driver also raises the events of the keys written by
+// other applications sharing the database. The prefix is only a filter: keys are stored as given.
+// It is ignored by the other drivers.
+func WithEventKeyPrefix(prefix string) Option {
+	return func(o *options) {
+		o.eventKeyPrefix = prefix
+	}
+}
+
+// WithTTLJitter makes the driver lengthen every TTL by a random delay of up to the given share of the TTL,
+// such as 0.1 for up to 10%, so that the entries written together with the same TTL do not all expire at once.
+// Entries without expiration are left as is. It replaces WithTTLJitterDuration, and WithoutTTLJitter overrides it.
+func WithTTLJitter(fraction float64) Option {
+	return func(o *options) {
+		o.ttlJitter = ttlJitter{fraction: fraction}
+	}
+}
+
+// WithTTLJitterDuration makes the driver lengthen every TTL by a random delay of up to bound. Like WithTTLJitter,
+// it leaves the entries without expiration as is. It replaces WithTTLJitter, and WithoutTTLJitter overrides it.
+func WithTTLJitterDuration(bound time.Duration) Option {
+	return func(o *options) {
+		o.ttlJitter = ttlJitter{absolute: bound}
+	}
+}
+
+// WithTTLJitterSource sets the source of the random delays added by WithTTLJitter and WithTTLJitterDuration,
+// so that tests can seed it, such as with rand.NewPCG, and get the same delays on every run. By default,
+// or if the source is nil, the global source of math/rand/v2 is used. The driver serializes the calls to the source.
+func WithTTLJitterSource(source rand.Source) Option {
+	return func(o *options) {
+		o.jitterSource = source
+	}
+}
+
+// WithoutTTLJitter makes the driver keep every TTL as given, even if WithTTLJitter or WithTTLJitterDuration is set,
+// before or after it. Tests can thus add it to the options of the application to get deterministic expirations.
+func WithoutTTLJitter() Option {
+	return func(o *options) {
+		o.noJitter = true
+	}
+}
@@ -0,0 +1,107 @@
+package driver
+
+// outageJournal records what the fallback cache of a driver changed while the driver was unavailable,
+// so that the driver drops its own copies once it recovers instead of serving the values they replaced.
+type outageJournal struct {
+	keys     map[string]struct{} // The keys written or deleted since the outage began or the cache was flushed.
+	patterns []string            // The patterns of DeleteByPattern and DeleteByPrefix.
+	tags     []string            // The tags invalidated with InvalidateTags.
+	flushed  bool                // Whether the cache was flushed, which makes the keys and patterns irrelevant.
+}
+
+// record adds the changes made under the write lock of the fallback cache to the journal.
+func (j *outageJournal) record(changes []logChange) {
+	for _, change := range changes {
+		if change.flush {
+			j.keys = make(map[string]struct{})
+			j.patterns, j.tags = nil, nil
+			j.flushed = true
+			continue
+		}
+		if !j.flushed {
+			j.keys[change.key] = struct{}{}
+		}
+	}
+}
+
+// empty reports whether the journal recorded no change.
+func (j *outageJournal) empty() bool {
+	return j == nil || !j.flushed && len(j.keys) == 0 && len(j.patterns) == 0 && len(j.tags) == 0
+}
+
+// merge adds the changes of the newer journal, recorded after those of j, to j.
+func (j *outageJournal) merge(newer *outageJournal) {
+	if newer == nil {
+		return
+	}
+	if newer.flushed {
+		*j = *newer
+		return
+	}
+	if j.flushed {
+		return
+	}
+	for key := range newer.keys {
+		j.keys[key] = struct{}{}
+	}
+	j.patterns = append(j.patterns, newer.patterns...)
+	j.tags = append(j.tags, newer.tags...)
+}
+
+// startJournal makes the cache record its changes in a new outage journal, unless one is already recorded.
+func (c *MemoryCache) startJournal() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.journal == nil {
+		c.journal = &outageJournal{keys: make(map[string]struct{})}
+	}
+}
+
+// swapJournal returns the journal recorded so far, nil if none was, and records the next changes
+// in a new journal.
+func (c *MemoryCache) swapJournal() *outageJournal {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	journal := c.journal
+	c.journal = &outageJournal{keys: make(map[string]struct{})}
+	return journal
+}
+
+// restoreJournal puts back a journal taken by swapJournal that could not be replayed, in front of the changes
+// recorded since.
+func (c *MemoryCache) restoreJournal(journal *outageJournal) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if journal == nil {
+		return
+	}
+	journal.merge(c.journal)
+	c.journal = journal
+}
+
+// endJournal calls end with the journal recorded so far, under the write lock of the cache so that no change
+// is made meanwhile, and stops recording the changes if end succeeds.
+func (c *MemoryCache) endJournal(end func(journal *outageJournal) error) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := end(c.journal); err != nil {
+		return err
+	}
+	c.journal = nil
+	return nil
+}
+
+// journalDelete records the deletions whose effect on Redis the fallback cache cannot see, on the keys it does not
+// hold, the patterns and the tags, unless no journal is recorded.
+func (c *MemoryCache) journalDelete(keys []string, patterns []string, tags []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.journal == nil || c.journal.flushed {
+		return
+	}
+	for _, key := range keys {
+		c.journal.keys[key] = struct{}{}
+	}
+	c.journal.patterns = append(c.journal.patterns, patterns...)
+	c.journal.tags = append(c.journal.tags, tags...)
+}
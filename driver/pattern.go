@@ -0,0 +1,96 @@
+package driver
+
+// matchPattern reports whether key matches the glob-style pattern using the
+// same rules as the Redis SCAN MATCH and KEYS commands: "*" matches any sequence
+// of characters, "?" matches a single character, "[abc]", "[^abc]" and "[a-z]"
+// match character classes, and a backslash escapes the following character.
+// An empty pattern matches every key.
+func matchPattern(pattern, key string) bool {
+	if pattern == "" {
+		return true
+	}
+	return matchGlob(pattern, key)
+}
+
+// matchGlob is the recursive matcher behind matchPattern.
+func matchGlob(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// collapse consecutive stars
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchGlob(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			pattern = pattern[1:]
+			negate := len(pattern) > 0 && pattern[0] == '^'
+			if negate {
+				pattern = pattern[1:]
+			}
+			matched := false
+			for len(pattern) > 0 && pattern[0] != ']' {
+				switch {
+				case pattern[0] == '\\' && len(pattern) >= 2:
+					pattern = pattern[1:]
+					if pattern[0] == key[0] {
+						matched = true
+					}
+				case len(pattern) >= 3 && pattern[1] == '-':
+					start, end := pattern[0], pattern[2]
+					if start > end {
+						start, end = end, start
+					}
+					if key[0] >= start && key[0] <= end {
+						matched = true
+					}
+					pattern = pattern[2:]
+				default:
+					if pattern[0] == key[0] {
+						matched = true
+					}
+				}
+				pattern = pattern[1:]
+			}
+			if negate {
+				matched = !matched
+			}
+			if !matched {
+				return false
+			}
+			key = key[1:]
+			if len(pattern) == 0 {
+				// unterminated class, treat the end of the pattern as the closing bracket
+				return len(key) == 0
+			}
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			key = key[1:]
+		}
+		pattern = pattern[1:]
+	}
+	return len(key) == 0
+}
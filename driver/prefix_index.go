@@ -0,0 +1,96 @@
+package driver
+
+// prefixIndex is a byte-wise trie over the keys of a MemoryCache.
+// It lets prefix lookups visit only the matching keys instead of the whole cache.
+// It is not safe for concurrent use; callers must hold the cache lock.
+type prefixIndex struct {
+	root *prefixNode
+}
+
+// prefixNode is a single node of the prefixIndex trie.
+type prefixNode struct {
+	children map[byte]*prefixNode // Child nodes keyed by the next byte of the key.
+	terminal bool                 // Whether a key ends at this node.
+}
+
+// newPrefixIndex creates an empty prefixIndex.
+func newPrefixIndex() *prefixIndex {
+	return &prefixIndex{root: &prefixNode{}}
+}
+
+// insert adds the key to the index. Inserting an existing key is a no-op.
+func (p *prefixIndex) insert(key string) {
+	node := p.root
+	for i := 0; i < len(key); i++ {
+		if node.children == nil {
+			node.children = make(map[byte]*prefixNode)
+		}
+		child, ok := node.children[key[i]]
+		if !ok {
+			child = &prefixNode{}
+			node.children[key[i]] = child
+		}
+		node = child
+	}
+	node.terminal = true
+}
+
+// remove deletes the key from the index and prunes the nodes left without keys.
+func (p *prefixIndex) remove(key string) {
+	p.removeFrom(p.root, key)
+}
+
+// removeFrom deletes key below node and reports whether node became empty.
+func (p *prefixIndex) removeFrom(node *prefixNode, key string) bool {
+	if key == "" {
+		node.terminal = false
+	} else if child, ok := node.children[key[0]]; ok && p.removeFrom(child, key[1:]) {
+		delete(node.children, key[0])
+	}
+	return !node.terminal && len(node.children) == 0
+}
+
+// keysWithPrefix returns every indexed key starting with prefix.
+func (p *prefixIndex) keysWithPrefix(prefix string) []string {
+	node := p.root
+	for i := 0; i < len(prefix); i++ {
+		child, ok := node.children[prefix[i]]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+	var keys []string
+	buf := []byte(prefix)
+	var walk func(n *prefixNode)
+	walk = func(n *prefixNode) {
+		if n.terminal {
+			keys = append(keys, string(buf))
+		}
+		for b, child := range n.children {
+			buf = append(buf, b)
+			walk(child)
+			buf = buf[:len(buf)-1]
+		}
+	}
+	walk(node)
+	return keys
+}
+
+// literalPrefix returns the part of a glob-style pattern that precedes its first
+// special character, which every matching key must start with.
+func literalPrefix(pattern string) string {
+	prefix := make([]byte, 0, len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?', '[':
+			return string(prefix)
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+		}
+		prefix = append(prefix, pattern[i])
+	}
+	return string(prefix)
+}
@@ -2,121 +2,443 @@ package driver
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	redis "github.com/redis/go-redis/v9"
 	"github.com/sibeur/go-cache/common"
 )
 
+// scanBatchSize is the COUNT hint passed to every SCAN call.
+const scanBatchSize = 100
+
 // RedisCache represents a cache driver that uses Redis as the underlying storage.
+// While it is unavailable, its operations follow the DegradedMode set with WithDegradedMode:
+// by default they log an error message and return zero values without error. The idempotent commands
+// failing with a transient error are retried according to the RetryPolicy set with WithRetry.
 type RedisCache struct {
-	client      *redis.Client // client is the Redis client used for cache operations.
-	isAvailable bool          // isAvailable indicates whether the Redis cache is available or not.
-	driverName  string        // driverName is the name of the Redis cache driver.
+	client      *redis.Client      // client is the Redis client used for cache operations.
+	isAvailable atomic.Bool        // isAvailable indicates whether the Redis cache is available or not.
+	driverName  string             // driverName is the name of the Redis cache driver.
+	logger      *cacheLogger       // logger is the structured logger of the cache operations.
+	stats       *statsRecorder     // stats holds the lock-free statistics of the cache operations.
+	events      eventHandlers      // events holds the handlers registered for the cache events.
+	eventsM     sync.Mutex         // eventsM guards stopEvents, subscribing and eventsOff.
+	stopEvents  context.CancelFunc // stopEvents ends the subscription of the event handlers, nil until one is registered.
+	subscribing bool               // subscribing tells that the subscription of the event handlers is in progress.
+	eventsOff   bool               // eventsOff tells that Close was called, so that no subscription starts anymore.
+	degraded    degradedPolicy     // degraded tells how the cache behaves while it is unavailable.
+	jitter      ttlJitter          // jitter is the random delay added to the TTLs.
+	fallback    *MemoryCache       // fallback serves the operations while the cache is unavailable, nil unless falling back to memory.
+	outageM     sync.Mutex         // outageM guards the switches to and from the fallback cache, stopWatch and closed.
+	stopWatch   chan struct{}      // stopWatch stops the health checks run during an outage, nil when none run.
+	closed      bool               // closed tells that Close was called, so that no health checks start anymore.
+	health      time.Duration      // health is the interval between two health checks during an outage.
+	keepLocal   bool               // keepLocal keeps the content of the fallback cache when Redis recovers.
+	retrier     *retrier           // retrier retries the idempotent commands failing with a transient error, nil for no retries.
+	eventPrefix string             // eventPrefix is the prefix of the keys whose events are raised, empty for every key.
 }
 
-// NewRedisCache creates a new instance of RedisCache using the provided Redis client.
+// NewRedisCache creates a new instance of RedisCache using the provided Redis client and options.
 // It initializes the cache and checks if the Redis server is available by pinging it.
 // If the server is unavailable, the cache will be marked as unavailable.
 // The function returns a pointer to the created RedisCache instance.
-func NewRedisCache(client *redis.Client) *RedisCache {
+func NewRedisCache(client *redis.Client, opts ...Option) *RedisCache {
 	driverName := "redis"
-	log.Printf("[%s] initiate cache", driverName)
+	o := newOptions(opts)
+	logger := newCacheLogger(driverName, o)
+	logger.info("initiate cache")
 	// ping redis
-	pong, _ := client.Ping(context.Background()).Result()
+	pong, err := client.Ping(context.Background()).Result()
 	available := true
 	if pong == "" {
-		log.Println(common.ErrCacheUnavailableMsg)
+		logger.warn(common.ErrCacheUnavailableMsg, slog.Any("error", err))
 		available = false
 	}
 
-	return &RedisCache{
-		client:      client,
-		isAvailable: available,
-		driverName:  driverName,
+	cache := &RedisCache{
+		client:     client,
+		driverName: driverName,
+		logger:     logger,
+		stats:      newStatsRecorder(),
+	}
+	cache.isAvailable.Store(true)
+	cache.degraded = newDegradedPolicy(o, logger, cache.stats)
+	cache.jitter = newTTLJitter(o)
+	cache.fallback = cache.degraded.newFallback(o, cache.jitter)
+	cache.eventPrefix = o.eventKeyPrefix
+	cache.health = o.healthInterval
+	if cache.health <= 0 {
+		cache.health = defaultHealthInterval
+	}
+	cache.keepLocal = o.keepFallback
+	if o.retry != nil {
+		cache.retrier = newRetrier(*o.retry, logger, cache.stats)
+	}
+	if !available {
+		cache.markDown(err)
 	}
+	return cache
 }
 
 // Get retrieves the value associated with the given key from the Redis cache.
+// If the key does not exist or has expired, it returns an empty string and ErrMiss.
 // If the cache is unavailable, it logs an error message and returns an empty string.
 // It returns the value and any error encountered during the retrieval process.
 func (r *RedisCache) Get(key string) (string, error) {
-	if !r.isAvailable {
-		log.Println(common.ErrCacheUnavailableMsg)
-		return "", nil
+	return r.GetCtx(context.Background(), key)
+}
+
+// GetCtx is like Get, but sends the Redis commands with the given context,
+// so that its deadline and cancellation apply.
+func (r *RedisCache) GetCtx(ctx context.Context, key string) (string, error) {
+	defer r.stats.observe(common.OpGet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.GetCtx(ctx, key)
+		}
+		r.stats.read(false)
+		return "", r.degraded.unavailableRead(common.OpGet)
+	}
+	val, err := idempotent(ctx, r, r.client.Get(ctx, key)).Result()
+	if err == redis.Nil {
+		r.stats.read(false)
+		return "", ErrMiss
 	}
-	ctx := context.Background()
-	val, err := r.client.Get(ctx, key).Result()
 	if err != nil {
-		return "", err
+		return "", r.stats.failed(err)
 	}
+	r.stats.read(true)
 
-	log.Printf("[%s] %s %s\n", r.driverName, common.GetCacheMsg, key)
+	r.logger.operation(common.OpGet, key)
 	return val, nil
 }
 
-// Set sets the value for the given key in the Redis cache.
+// Set sets the value for the given key in the Redis cache, removing the key from the tags it was stored with.
 // If the cache is unavailable, it logs an error message and returns nil.
 // It returns an error if there was a problem setting the value in the cache.
 func (r *RedisCache) Set(key string, value string) error {
-	if !r.isAvailable {
-		log.Println(common.ErrCacheUnavailableMsg)
-		return nil
+	return r.SetCtx(context.Background(), key, value)
+}
+
+// SetCtx is like Set, but sends the Redis commands with the given context,
+// so that its deadline and cancellation apply.
+func (r *RedisCache) SetCtx(ctx context.Context, key string, value string) error {
+	defer r.stats.observe(common.OpSet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.SetCtx(ctx, key, value)
+		}
+		return r.degraded.unavailable(common.OpSet)
 	}
-	ctx := context.Background()
-	log.Printf("[%s] %s %s\n", r.driverName, common.SetCacheMsg, key)
-	return r.client.Set(ctx, key, value, 0).Err()
+	r.logger.operation(common.OpSet, key)
+	return r.recordSet(r.set(ctx, key, value, 0))
 }
 
-// SetWithExpire sets a key-value pair in the Redis cache with an expiration time.
+// SetWithExpire sets a key-value pair in the Redis cache with an expiration time,
+// removing the key from the tags it was stored with.
 // If the cache is unavailable, it logs an error message and returns nil.
 // It takes the key, value, and time-to-live (TTL in seconds) as parameters.
 // The TTL specifies the duration for which the key-value pair should be stored in the cache.
 // It returns an error if there was a problem setting the key-value pair in the cache.
 func (r *RedisCache) SetWithExpire(key string, value string, ttl uint64) error {
-	if !r.isAvailable {
-		log.Println(common.ErrCacheUnavailableMsg)
-		return nil
+	return r.SetWithExpireCtx(context.Background(), key, value, ttl)
+}
+
+// SetWithExpireCtx is like SetWithExpire, but sends the Redis commands with the given context,
+// so that its deadline and cancellation apply.
+func (r *RedisCache) SetWithExpireCtx(ctx context.Context, key string, value string, ttl uint64) error {
+	defer r.stats.observe(common.OpSet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.SetWithExpireCtx(ctx, key, value, ttl)
+		}
+		return r.degraded.unavailable(common.OpSet)
+	}
+	r.logger.operation(common.OpSet, key, slog.Uint64("ttl", ttl))
+	return r.recordSet(r.set(ctx, key, value, r.jitter.apply(ttl)))
+}
+
+// set stores the value with a plain SET, zero ttl for no expiration, and deletes the index of the tags of the key
+// in the same MULTI/EXEC transaction. Its former tag sets may still list the key, but without the index
+// they no longer invalidate it, see liveTagMembersScript.
+func (r *RedisCache) set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return r.retryIdempotent(ctx, "multi", func() error {
+		_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, value, ttl)
+			pipe.Unlink(ctx, keyTagsPrefix+key)
+			return nil
+		})
+		return err
+	})
+}
+
+// recordSet counts a successful write in the statistics, or an error if err is not nil, and returns err unchanged.
+func (r *RedisCache) recordSet(err error) error {
+	if err == nil {
+		r.stats.sets.Add(1)
 	}
-	ctx := context.Background()
-	log.Printf("[%s] %s %s with TTL %d\n", r.driverName, common.SetCacheMsg, key, ttl)
-	return r.client.Set(ctx, key, value, time.Duration(ttl)*time.Second).Err()
+	return r.stats.failed(err)
 }
 
-// Delete removes the cache entry with the specified key from the Redis cache.
+// recordDelete counts the removed keys in the statistics, or an error if err is not nil, and returns both unchanged.
+func (r *RedisCache) recordDelete(removed int64, err error) (int64, error) {
+	r.stats.deletes.Add(uint64(removed))
+	return removed, r.stats.failed(err)
+}
+
+// Delete removes the cache entry with the specified key from the Redis cache, together with its tag memberships.
 // If the cache is unavailable, it logs an error message and returns nil.
 // It returns an error if there was a problem deleting the cache entry.
 func (r *RedisCache) Delete(key string) error {
-	if !r.isAvailable {
-		log.Println(common.ErrCacheUnavailableMsg)
-		return nil
+	return r.DeleteCtx(context.Background(), key)
+}
+
+// DeleteCtx is like Delete, but sends the Redis commands with the given context,
+// so that its deadline and cancellation apply.
+func (r *RedisCache) DeleteCtx(ctx context.Context, key string) error {
+	defer r.stats.observe(common.OpDelete, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			r.fallback.journalDelete([]string{key}, nil, nil)
+			return r.fallback.DeleteCtx(ctx, key)
+		}
+		return r.degraded.unavailable(common.OpDelete)
+	}
+	r.logger.operation(common.OpDelete, key)
+	_, err := r.recordDelete(r.deleteKeys(ctx, []string{key}))
+	return err
+}
+
+// DeleteByPattern removes every key matching the glob-style pattern and returns how many were removed.
+// Matching keys are collected with the non-blocking SCAN command and removed in batches with UNLINK,
+// so the Redis server is never blocked by a single large deletion.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	defer r.stats.observe(common.OpDelete, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			r.fallback.journalDelete(nil, []string{pattern}, nil)
+			return r.fallback.DeleteByPattern(ctx, pattern)
+		}
+		return 0, r.degraded.unavailable(common.OpDelete)
+	}
+	r.logger.operation(common.OpDelete, pattern)
+	return r.recordDelete(r.unlinkMatching(ctx, pattern))
+}
+
+// DeleteByPrefix removes every key starting with the given prefix and returns how many were removed.
+// The prefix is matched literally, glob special characters in it are escaped.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) DeleteByPrefix(ctx context.Context, prefix string) (int64, error) {
+	defer r.stats.observe(common.OpDelete, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			r.fallback.journalDelete(nil, []string{escapePattern(prefix) + "*"}, nil)
+			return r.fallback.DeleteByPrefix(ctx, prefix)
+		}
+		return 0, r.degraded.unavailable(common.OpDelete)
+	}
+	r.logger.operation(common.OpDelete, prefix+"*")
+	return r.recordDelete(r.unlinkMatching(ctx, escapePattern(prefix)+"*"))
+}
+
+// unlinkMatching scans the keys matching pattern and unlinks them in batches of scanBatchSize,
+// leaving the tag sets and tag indexes out.
+func (r *RedisCache) unlinkMatching(ctx context.Context, pattern string) (int64, error) {
+	var removed int64
+	batch := make([]string, 0, scanBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := r.deleteKeys(ctx, batch)
+		removed += n
+		batch = batch[:0]
+		return err
+	}
+
+	iter := r.client.Scan(ctx, 0, pattern, scanBatchSize).Iterator()
+	for iter.Next(ctx) {
+		if isInternalKey(iter.Val()) {
+			continue
+		}
+		batch = append(batch, iter.Val())
+		if len(batch) == scanBatchSize {
+			if err := flush(); err != nil {
+				return removed, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return removed, err
 	}
-	ctx := context.Background()
-	log.Printf("[%s] %s %s\n", r.driverName, common.DeleteCacheMsg, key)
-	return r.client.Del(ctx, key).Err()
+	return removed, flush()
+}
+
+// escapePattern escapes the glob special characters in s so that it matches itself literally.
+func escapePattern(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
 }
 
 // Flush deletes all the keys in the cache.
 func (r *RedisCache) Flush() error {
-	if !r.isAvailable {
-		log.Println(common.ErrCacheUnavailableMsg)
-		return nil
+	return r.FlushCtx(context.Background())
+}
+
+// FlushCtx is like Flush, but sends the Redis commands with the given context,
+// so that its deadline and cancellation apply.
+func (r *RedisCache) FlushCtx(ctx context.Context) error {
+	defer r.stats.observe(common.OpFlush, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.FlushCtx(ctx)
+		}
+		return r.degraded.unavailable(common.OpFlush)
+	}
+	r.logger.operation(common.OpFlush, "")
+	return r.stats.failed(idempotent(ctx, r, r.client.FlushAll(ctx)).Err())
+}
+
+// Scan calls fn for every key in the Redis database matching the glob-style pattern.
+// It walks the keyspace with the non-blocking SCAN command, so keys added or removed
+// during the iteration may or may not be reported, and a key may be reported more than once.
+// An empty pattern matches every key. The tag sets and tag indexes of the driver are never reported.
+// Iteration stops as soon as fn returns false or the context is done.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (r *RedisCache) Scan(ctx context.Context, pattern string, fn func(key string) bool) error {
+	defer r.stats.observe(common.OpScan, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.Scan(ctx, pattern, fn)
+		}
+		return r.degraded.unavailable(common.OpScan)
+	}
+	if pattern == "" {
+		pattern = "*"
+	}
+	r.logger.operation(common.OpScan, pattern)
+	iter := r.client.Scan(ctx, 0, pattern, scanBatchSize).Iterator()
+	for iter.Next(ctx) {
+		if isInternalKey(iter.Val()) {
+			continue
+		}
+		if !fn(iter.Val()) {
+			return nil
+		}
+	}
+	return r.stats.failed(iter.Err())
+}
+
+// Len returns the number of keys stored in the Redis database, leaving out the tag sets and tag indexes
+// of the driver, which are found with a SCAN of their prefix.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) Len(ctx context.Context) (int64, error) {
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.Len(ctx)
+		}
+		return 0, r.degraded.unavailable(common.OpScan)
 	}
-	ctx := context.Background()
-	log.Printf("[%s] %s\n", r.driverName, common.FlushCacheMsg)
-	return r.client.FlushAll(ctx).Err()
+	return r.countKeys(ctx)
+}
+
+// countKeys returns the DBSIZE of the database minus its tag sets and tag indexes.
+func (r *RedisCache) countKeys(ctx context.Context) (int64, error) {
+	total, err := idempotent(ctx, r, r.client.DBSize(ctx)).Result()
+	if err != nil {
+		return 0, err
+	}
+	iter := r.client.Scan(ctx, 0, internalKeyPattern, scanBatchSize).Iterator()
+	for iter.Next(ctx) {
+		if isInternalKey(iter.Val()) {
+			total--
+		}
+	}
+	return max(total, 0), iter.Err()
+}
+
+// statsTimeout bounds the commands Stats sends to read the item count and the server statistics.
+const statsTimeout = time.Second
+
+// Stats returns a snapshot of the statistics of the Redis cache.
+// Hits, misses, sets, deletes, errors and latencies are counted by this client. The item count is the one of Len,
+// so it covers the whole Redis database but the tag sets and tag indexes. The evictions and expirations are read
+// from the INFO stats command, so they cover the whole server. They are left at zero if the cache is unavailable
+// or the commands fail. The commands are sent with a context limited to statsTimeout, so that Stats does not hang
+// on a stalled server.
+func (r *RedisCache) Stats() Stats {
+	stats := r.stats.snapshot()
+	if !r.isAvailable.Load() {
+		return stats
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+	if items, err := r.countKeys(ctx); err == nil {
+		stats.Items = items
+	}
+	if info, err := r.client.Info(ctx, "stats").Result(); err == nil {
+		stats.Evictions = parseInfoField(info, "evicted_keys")
+		stats.Expirations = parseInfoField(info, "expired_keys")
+	}
+	return stats
+}
+
+// parseInfoField returns the numeric value of the field in the output of the INFO command, or zero if it is missing.
+func parseInfoField(info string, field string) uint64 {
+	for _, line := range strings.Split(info, "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), field+":")
+		if !ok {
+			continue
+		}
+		n, _ := strconv.ParseUint(value, 10, 64)
+		return n
+	}
+	return 0
 }
 
 // IsCacheAvailable checks if the Redis cache is available.
 // It returns true if the cache is available, otherwise false.
 func (r *RedisCache) IsCacheAvailable() bool {
-	return r.isAvailable
+	return r.isAvailable.Load()
 }
 
-// SetCacheAvailable sets the availability status of the Redis cache.
+// SetCacheAvailable sets the availability status of the Redis cache. It stops the health checks of an outage,
+// so that Redis made unavailable stays so until SetCacheAvailable(true) is called.
+// When the cache falls back to memory, the changes made in the local cache in the meantime are replayed on Redis
+// before it is available again, and the local cache is flushed. If they cannot be replayed, the cache keeps serving
+// from the local cache and the health checks run until Redis recovers.
 func (r *RedisCache) SetCacheAvailable(available bool) {
-	r.isAvailable = available
+	r.outageM.Lock()
+	r.stopWatching()
+	if !available {
+		if r.fallback != nil {
+			r.fallback.startJournal()
+		}
+		r.isAvailable.Store(false)
+		r.outageM.Unlock()
+		return
+	}
+	recovered := r.recover()
+	if !recovered {
+		r.startWatch()
+	}
+	r.outageM.Unlock()
+	if recovered {
+		r.listenEvents()
+	}
 }
 
 // GetDriverName returns the name of the Redis cache driver.
@@ -0,0 +1,265 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/common"
+)
+
+// keyspaceEventFlags are the notify-keyspace-events flags the subscriptions rely on:
+// keyevent notifications (E) for generic (g), string ($), expired (x) and evicted (e) events.
+const keyspaceEventFlags = "Eg$xe"
+
+const (
+	// minResubscribeDelay is the delay before the first attempt to restore a broken subscription.
+	minResubscribeDelay = 100 * time.Millisecond
+	// maxResubscribeDelay caps the delay between two attempts to restore a broken subscription.
+	maxResubscribeDelay = 30 * time.Second
+	// subscribeTimeout bounds the commands enabling the notifications and subscribing to them,
+	// so that a stalled server does not block the subscription forever.
+	subscribeTimeout = 5 * time.Second
+)
+
+// keyspaceEvents maps the keyevent notification names to the events they raise.
+// Redis notifies both DEL and UNLINK as "del".
+var keyspaceEvents = map[string]Event{
+	"set":     {Type: EventSet},
+	"del":     {Type: EventDelete},
+	"expired": {Type: EventExpire},
+	"evicted": {Type: EventEvict, Reason: EvictReasonCapacity},
+}
+
+// allEventTypes lists every event type, in the order they are documented.
+var allEventTypes = []EventType{EventSet, EventDelete, EventExpire, EventEvict}
+
+// Subscribe calls handler for every event of the given types that happens in the database of the Redis
+// client, until the context is done. The events come from the __keyevent@<db>__ notification channels;
+// Subscribe enables the notifications it needs on the server with CONFIG SET, and if the server refuses
+// CONFIG SET, they must be enabled in its configuration instead.
+//
+// The events also report the changes made by other clients and carry no value, since it is gone by the
+// time they are received. The internal tag sets of the driver never raise events. The notifications cover the
+// whole database: use WithEventKeyPrefix to only receive the events of the keys of this cache.
+// Events are consumed in a background goroutine. When the connection breaks, the subscription is restored
+// with an exponential backoff, re-enabling the notifications in case the server restarted; the events
+// raised while disconnected are lost.
+// Subscribe only returns an error if the initial subscription fails.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (r *RedisCache) Subscribe(ctx context.Context, events []EventType, handler EventHandler) error {
+	if !r.isAvailable.Load() {
+		return r.degraded.unavailable(common.OpSubscribe)
+	}
+	channels := r.keyeventChannels(events)
+	pubsub, err := r.subscribeKeyevents(ctx, channels)
+	if err != nil {
+		return err
+	}
+	r.logger.operation(common.OpSubscribe, "", slog.Any("events", events))
+	go r.consumeKeyevents(ctx, pubsub, channels, handler)
+	return nil
+}
+
+// keyeventChannels returns the keyevent channels of the database of the client raising the given event types.
+func (r *RedisCache) keyeventChannels(events []EventType) []string {
+	prefix := r.keyeventPrefix()
+	var channels []string
+	for name, event := range keyspaceEvents {
+		for _, eventType := range events {
+			if event.Type == eventType {
+				channels = append(channels, prefix+name)
+				break
+			}
+		}
+	}
+	return channels
+}
+
+// keyeventPrefix returns the prefix of the keyevent channels of the database of the client.
+func (r *RedisCache) keyeventPrefix() string {
+	return fmt.Sprintf("__keyevent@%d__:", r.client.Options().DB)
+}
+
+// subscribeKeyevents enables the keyspace notifications and subscribes to the channels, waiting for the server
+// to confirm the subscription for up to subscribeTimeout. The subscription is closed once the context is done.
+func (r *RedisCache) subscribeKeyevents(ctx context.Context, channels []string) (*redis.PubSub, error) {
+	setupCtx, cancel := context.WithTimeout(ctx, subscribeTimeout)
+	defer cancel()
+	if err := r.enableKeyspaceEvents(setupCtx); err != nil {
+		r.logger.warn("cannot enable keyspace notifications", slog.Any("error", err))
+	}
+	pubsub := r.client.Subscribe(setupCtx, channels...)
+	if _, err := pubsub.Receive(setupCtx); err != nil {
+		_ = pubsub.Close()
+		return nil, r.stats.failed(err)
+	}
+	context.AfterFunc(ctx, func() { _ = pubsub.Close() })
+	return pubsub, nil
+}
+
+// enableKeyspaceEvents adds the keyspaceEventFlags missing from the notify-keyspace-events setting of the server.
+func (r *RedisCache) enableKeyspaceEvents(ctx context.Context) error {
+	config, err := r.client.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return err
+	}
+	flags := config["notify-keyspace-events"]
+	updated := flags
+	for _, flag := range keyspaceEventFlags {
+		// "A" is an alias for every event class except keyevent, keyspace, new-key and miss events.
+		if !strings.ContainsRune(updated, flag) && (flag == 'E' || !strings.ContainsRune(updated, 'A')) {
+			updated += string(flag)
+		}
+	}
+	if updated == flags {
+		return nil
+	}
+	return r.client.ConfigSet(ctx, "notify-keyspace-events", updated).Err()
+}
+
+// consumeKeyevents passes the notifications received on pubsub to handler until the context is done,
+// restoring the subscription whenever it breaks.
+func (r *RedisCache) consumeKeyevents(ctx context.Context, pubsub *redis.PubSub, channels []string, handler EventHandler) {
+	prefix := r.keyeventPrefix()
+	for {
+		msg, err := pubsub.ReceiveMessage(ctx)
+		if err == nil && ctx.Err() == nil {
+			if event, ok := parseKeyevent(msg, prefix, r.eventPrefix); ok {
+				handler(event)
+			}
+			continue
+		}
+		_ = pubsub.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		r.logger.warn("keyspace notification subscription lost", slog.Any("error", err))
+		if pubsub = r.resubscribeKeyevents(ctx, channels); pubsub == nil {
+			return
+		}
+	}
+}
+
+// resubscribeKeyevents retries to subscribe to the channels with an exponential backoff until it succeeds,
+// or returns nil once the context is done.
+func (r *RedisCache) resubscribeKeyevents(ctx context.Context, channels []string) *redis.PubSub {
+	delay := minResubscribeDelay
+	for {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		pubsub, err := r.subscribeKeyevents(ctx, channels)
+		if err == nil {
+			r.logger.info("keyspace notification subscription restored")
+			return pubsub
+		}
+		r.logger.warn("cannot restore keyspace notification subscription", slog.Any("error", err), slog.Duration("retry", delay))
+		delay = min(2*delay, maxResubscribeDelay)
+	}
+}
+
+// parseKeyevent returns the event described by a keyevent notification, or false if the notification is not
+// one of keyspaceEvents, concerns an internal tag set or index, or a key not starting with keyPrefix.
+func parseKeyevent(msg *redis.Message, prefix string, keyPrefix string) (Event, bool) {
+	event, ok := keyspaceEvents[strings.TrimPrefix(msg.Channel, prefix)]
+	if !ok || isInternalKey(msg.Payload) || !strings.HasPrefix(msg.Payload, keyPrefix) {
+		return Event{}, false
+	}
+	event.Key = msg.Payload
+	return event, true
+}
+
+// OnSet registers a handler called whenever a string value is stored in the Redis database.
+// The events come from a keyspace notification subscription, see Subscribe.
+func (r *RedisCache) OnSet(handler EventHandler) {
+	r.events.on(EventSet, handler)
+	r.listenEvents()
+}
+
+// OnDelete registers a handler called whenever a key is deleted from the Redis database.
+// The events come from a keyspace notification subscription, see Subscribe.
+func (r *RedisCache) OnDelete(handler EventHandler) {
+	r.events.on(EventDelete, handler)
+	r.listenEvents()
+}
+
+// OnExpire registers a handler called whenever Redis removes a key because its TTL elapsed.
+// The events come from a keyspace notification subscription, see Subscribe.
+func (r *RedisCache) OnExpire(handler EventHandler) {
+	r.events.on(EventExpire, handler)
+	r.listenEvents()
+}
+
+// OnEvict registers a handler called whenever Redis evicts a key to honor its maxmemory policy.
+// The events come from a keyspace notification subscription, see Subscribe.
+// Call Close to stop receiving events.
+func (r *RedisCache) OnEvict(handler EventHandler) {
+	r.events.on(EventEvict, handler)
+	r.listenEvents()
+}
+
+// Close stops the subscription started by the first registered event handler and the health checks of an outage,
+// and closes the fallback cache, if any. The handlers registered afterwards are not subscribed anymore.
+// It does not close the Redis client.
+func (r *RedisCache) Close() error {
+	r.outageM.Lock()
+	r.closed = true
+	r.stopWatching()
+	r.outageM.Unlock()
+
+	r.eventsM.Lock()
+	r.eventsOff = true
+	if r.stopEvents != nil {
+		r.stopEvents()
+		r.stopEvents = nil
+	}
+	r.eventsM.Unlock()
+
+	if r.fallback != nil {
+		return r.fallback.Close()
+	}
+	return nil
+}
+
+// listenEvents subscribes to every event type for the registered handlers, unless it was already done or is
+// in progress. While the cache is unavailable, the subscription is left to SetCacheAvailable, which calls
+// listenEvents again once the cache is available; the same goes for a subscription that failed.
+// The subscription is made outside eventsM, so that a stalled server does not block Close or the other callers.
+func (r *RedisCache) listenEvents() {
+	r.eventsM.Lock()
+	if r.stopEvents != nil || r.subscribing || r.eventsOff || !r.events.active.Load() || !r.isAvailable.Load() {
+		r.eventsM.Unlock()
+		return
+	}
+	r.subscribing = true
+	r.eventsM.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	channels := r.keyeventChannels(allEventTypes)
+	pubsub, err := r.subscribeKeyevents(ctx, channels)
+
+	r.eventsM.Lock()
+	defer r.eventsM.Unlock()
+	r.subscribing = false
+	if err != nil {
+		cancel()
+		r.logger.warn("cannot subscribe to keyspace notifications", slog.Any("error", err))
+		return
+	}
+	if r.eventsOff {
+		cancel()
+		return
+	}
+	go r.consumeKeyevents(ctx, pubsub, channels, func(event Event) {
+		r.events.dispatch([]Event{event})
+	})
+	r.stopEvents = cancel
+}
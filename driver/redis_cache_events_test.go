@@ -0,0 +1,154 @@
+package driver_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestRedisCache_Events(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+	if err := client.ConfigSet(context.Background(), "notify-keyspace-events", "").Err(); err != nil {
+		t.Skipf("Redis server does not support keyspace notifications: %v", err)
+	}
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+	defer cache.Close()
+	recorder := &eventRecorder{}
+	cache.OnSet(recorder.handle)
+	cache.OnDelete(recorder.handle)
+
+	// Give the subscription time to be established
+	time.Sleep(100 * time.Millisecond)
+
+	_ = cache.Set("events:key1", "value1")
+	_ = cache.Delete("events:key1")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(recorder.received()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	events := recorder.received()
+	if len(events) != 2 ||
+		events[0] != (driver.Event{Type: driver.EventSet, Key: "events:key1"}) ||
+		events[1] != (driver.Event{Type: driver.EventDelete, Key: "events:key1"}) {
+		t.Errorf("Expected a set and a delete event for events:key1, but got %v", events)
+	}
+}
+
+func TestRedisCache_Subscribe(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recorder := &eventRecorder{}
+	err := cache.Subscribe(ctx, []driver.EventType{driver.EventExpire, driver.EventEvict}, recorder.handle)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// Publish the notifications the server would send, so that the test does not depend on its configuration
+	_ = client.Publish(ctx, "__keyevent@0__:expired", "go-cache:tag:tag1").Err()
+	_ = client.Publish(ctx, "__keyevent@0__:expired", "session:1").Err()
+	_ = client.Publish(ctx, "__keyevent@0__:evicted", "session:2").Err()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(recorder.received()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	expected := []driver.Event{
+		{Type: driver.EventExpire, Key: "session:1"},
+		{Type: driver.EventEvict, Key: "session:2", Reason: driver.EvictReasonCapacity},
+	}
+	events := recorder.received()
+	if len(events) != len(expected) || events[0] != expected[0] || events[1] != expected[1] {
+		t.Errorf("Expected events %v, but got %v", expected, events)
+	}
+
+	// Events that were not subscribed to, or raised once the context is done, are not received
+	_ = client.Publish(ctx, "__keyevent@0__:del", "session:3").Err()
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	_ = client.Publish(context.Background(), "__keyevent@0__:expired", "session:4").Err()
+	time.Sleep(50 * time.Millisecond)
+	if events := recorder.received(); len(events) != len(expected) {
+		t.Errorf("Expected no more events, but got %v", events[len(expected):])
+	}
+}
+
+func TestRedisCache_Subscribe_UnavailableCache(t *testing.T) {
+	// Create a Redis client for testing
+	client := redis.NewClient(&redis.Options{
+		Addr: "invalid",
+	})
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	err := cache.Subscribe(context.Background(), []driver.EventType{driver.EventExpire}, func(driver.Event) {})
+	if err != nil {
+		t.Errorf("Failed to subscribe: %v", err)
+	}
+}
+
+func TestRedisCache_Events_RegisteredWhileUnavailable(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Register a handler while the cache is unavailable, in the default fail-open mode
+	cache := driver.NewRedisCache(client)
+	defer cache.Close()
+	cache.SetCacheAvailable(false)
+	recorder := &eventRecorder{}
+	cache.OnExpire(recorder.handle)
+
+	// The handler is subscribed once the cache is available again
+	cache.SetCacheAvailable(true)
+	time.Sleep(100 * time.Millisecond)
+	_ = client.Publish(context.Background(), "__keyevent@0__:expired", "session:1").Err()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(recorder.received()) < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	events := recorder.received()
+	if len(events) != 1 || events[0] != (driver.Event{Type: driver.EventExpire, Key: "session:1"}) {
+		t.Errorf("Expected an expire event for session:1, but got %v", events)
+	}
+}
+
+func TestRedisCache_Subscribe_EventKeyPrefix(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Only the events of the keys of the cache are raised
+	cache := driver.NewRedisCache(client, driver.WithEventKeyPrefix("myapp:"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recorder := &eventRecorder{}
+	if err := cache.Subscribe(ctx, []driver.EventType{driver.EventExpire}, recorder.handle); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	_ = client.Publish(ctx, "__keyevent@0__:expired", "otherapp:session:1").Err()
+	_ = client.Publish(ctx, "__keyevent@0__:expired", "myapp:session:1").Err()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(recorder.received()) < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	events := recorder.received()
+	if len(events) != 1 || events[0] != (driver.Event{Type: driver.EventExpire, Key: "myapp:session:1"}) {
+		t.Errorf("Expected an expire event for myapp:session:1 only, but got %v", events)
+	}
+}
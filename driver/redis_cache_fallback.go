@@ -0,0 +1,167 @@
+package driver
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// defaultHealthInterval is the interval between two health checks of Redis.
+const defaultHealthInterval = time.Second
+
+// maxReplayRounds is the number of times the outage journal is replayed while the local cache keeps serving,
+// before the last replay blocks its writes.
+const maxReplayRounds = 3
+
+// markDown makes the cache unavailable after Redis failed with err. When the cache falls back to memory,
+// the local cache serves the operations and records its changes, and Redis is pinged until it answers again.
+func (r *RedisCache) markDown(err error) {
+	r.outageM.Lock()
+	defer r.outageM.Unlock()
+
+	if !r.isAvailable.Load() {
+		return
+	}
+	if r.fallback != nil {
+		r.fallback.startJournal()
+	}
+	r.isAvailable.Store(false)
+	if r.fallback != nil {
+		r.logger.warn("redis is down, switching to the local cache", slog.Any("error", err))
+		r.startWatch()
+	}
+}
+
+// recover makes the cache available again and reports whether it succeeded. When the cache falls back to memory,
+// the changes recorded by the local cache during the outage are replayed on Redis first, see replayJournal,
+// then the local cache is flushed unless it is kept. The caller must hold outageM, and subscribe the event
+// handlers with listenEvents once it released it.
+func (r *RedisCache) recover() bool {
+	if r.isAvailable.Load() {
+		return true
+	}
+	if r.fallback == nil {
+		r.isAvailable.Store(true)
+		return true
+	}
+	if err := r.replayJournal(); err != nil {
+		r.logger.warn("cannot replay the outage on redis", slog.Any("error", err))
+		return false
+	}
+	if !r.keepLocal {
+		_ = r.fallback.Flush()
+	}
+	r.logger.info("redis is back, switching from the local cache")
+	return true
+}
+
+// replayJournal replays the outage journal of the fallback cache on Redis, then makes the cache available.
+// The cache stays unavailable during the replays, so that no write reaches Redis before the deletions it must
+// follow: the local cache keeps serving and records its changes in a new journal, replayed in turn, up to
+// maxReplayRounds times. The last replay runs under the lock of the local cache, which holds its writes until
+// the cache is available.
+func (r *RedisCache) replayJournal() error {
+	for round := 0; round < maxReplayRounds; round++ {
+		journal := r.fallback.swapJournal()
+		if journal.empty() {
+			break
+		}
+		if err := r.replayOutage(journal); err != nil {
+			r.fallback.restoreJournal(journal)
+			return err
+		}
+	}
+	return r.fallback.endJournal(func(journal *outageJournal) error {
+		if err := r.replayOutage(journal); err != nil {
+			return err
+		}
+		r.isAvailable.Store(true)
+		return nil
+	})
+}
+
+// replayOutage deletes from Redis what the local cache changed during the outage: every key if it was flushed,
+// or else the keys written or deleted locally, the keys matching the deleted patterns and the entries of the
+// invalidated tags, so that Redis does not serve the values they replaced.
+func (r *RedisCache) replayOutage(journal *outageJournal) error {
+	if journal == nil {
+		return nil
+	}
+	ctx := context.Background()
+	if journal.flushed {
+		return idempotent(ctx, r, r.client.FlushAll(ctx)).Err()
+	}
+	keys := make([]string, 0, len(journal.keys))
+	for key := range journal.keys {
+		keys = append(keys, key)
+	}
+	for start := 0; start < len(keys); start += scanBatchSize {
+		if _, err := r.deleteKeys(ctx, keys[start:min(start+scanBatchSize, len(keys))]); err != nil {
+			return err
+		}
+	}
+	for _, pattern := range journal.patterns {
+		if _, err := r.unlinkMatching(ctx, pattern); err != nil {
+			return err
+		}
+	}
+	_, err := r.unlinkTags(ctx, journal.tags)
+	return err
+}
+
+// startWatch starts the health checks of an outage, unless they already run, the cache does not fall back
+// to memory or it is closed. The caller must hold outageM.
+func (r *RedisCache) startWatch() {
+	if r.stopWatch != nil || r.fallback == nil || r.closed {
+		return
+	}
+	r.stopWatch = make(chan struct{})
+	go r.watch(r.stopWatch)
+}
+
+// stopWatching stops the health checks of an outage, if they run. The caller must hold outageM.
+func (r *RedisCache) stopWatching() {
+	if r.stopWatch != nil {
+		close(r.stopWatch)
+		r.stopWatch = nil
+	}
+}
+
+// watch pings Redis at every health check interval until it answers and the cache recovers, or stop is closed.
+// It also removes the expired entries of the local cache, which has no cleanup routine of its own.
+func (r *RedisCache) watch(stop chan struct{}) {
+	ticker := time.NewTicker(r.health)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		r.fallback.cleanupExpired()
+		ctx, cancel := context.WithTimeout(context.Background(), r.health)
+		err := r.client.Ping(ctx).Err()
+		cancel()
+		if err != nil {
+			continue
+		}
+
+		r.outageM.Lock()
+		select {
+		case <-stop:
+			// Stopped while pinging
+			r.outageM.Unlock()
+			return
+		default:
+		}
+		recovered := r.recover()
+		if recovered {
+			r.stopWatch = nil
+		}
+		r.outageM.Unlock()
+		if recovered {
+			r.listenEvents()
+			return
+		}
+	}
+}
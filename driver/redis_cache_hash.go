@@ -0,0 +1,119 @@
+package driver
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/common"
+)
+
+// HGet retrieves the value of a field of the Redis hash stored under the given key.
+// If the cache is unavailable, it logs an error message and returns an empty string.
+// If the key or the field does not exist, it returns an empty string and ErrMiss.
+func (r *RedisCache) HGet(key string, field string) (string, error) {
+	defer r.stats.observe(common.OpGet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.HGet(key, field)
+		}
+		return "", r.degraded.unavailableRead(common.OpGet)
+	}
+	ctx := context.Background()
+	val, err := idempotent(ctx, r, r.client.HGet(ctx, key, field)).Result()
+	if err == redis.Nil {
+		r.stats.read(false)
+		return "", ErrMiss
+	}
+	if err := r.stats.lookup(true, err); err != nil {
+		return "", err
+	}
+
+	r.logger.operation(common.OpGet, key, slog.String("field", field))
+	return val, nil
+}
+
+// HGetAll retrieves every field of the Redis hash stored under the given key.
+// If the key does not exist, it returns an empty map.
+// If the cache is unavailable, it logs an error message and returns an empty map.
+func (r *RedisCache) HGetAll(key string) (map[string]string, error) {
+	defer r.stats.observe(common.OpGet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.HGetAll(key)
+		}
+		return map[string]string{}, r.degraded.unavailable(common.OpGet)
+	}
+	ctx := context.Background()
+	fields, err := idempotent(ctx, r, r.client.HGetAll(ctx, key)).Result()
+	if err := r.stats.lookup(len(fields) > 0, err); err != nil {
+		return nil, err
+	}
+
+	r.logger.operation(common.OpGet, key)
+	return fields, nil
+}
+
+// HSet sets the given fields of the Redis hash stored under the given key, creating the hash if needed.
+// The expiration of an existing hash is kept.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (r *RedisCache) HSet(key string, fields map[string]string) error {
+	defer r.stats.observe(common.OpSet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.HSet(key, fields)
+		}
+		return r.degraded.unavailable(common.OpSet)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpSet, key)
+	return r.stats.wrote(idempotent(ctx, r, r.client.HSet(ctx, key, fields)).Err())
+}
+
+// HSetWithExpire sets the given fields of the Redis hash stored under the given key, creating the hash if needed,
+// and sets the TTL (in seconds) of the whole hash. A TTL of zero removes the expiration of the hash.
+// Both commands are sent in a single MULTI/EXEC transaction.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (r *RedisCache) HSetWithExpire(key string, fields map[string]string, ttl uint64) error {
+	defer r.stats.observe(common.OpSet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.HSetWithExpire(key, fields, ttl)
+		}
+		return r.degraded.unavailable(common.OpSet)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpSet, key, slog.Uint64("ttl", ttl))
+	err := r.retryIdempotent(ctx, "multi", func() error {
+		_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key, fields)
+			if ttl == 0 {
+				pipe.Persist(ctx, key)
+			} else {
+				pipe.PExpire(ctx, key, r.jitter.apply(ttl))
+			}
+			return nil
+		})
+		return err
+	})
+	return r.stats.wrote(err)
+}
+
+// HDel removes the given fields from the Redis hash stored under the given key.
+// Redis deletes the hash once its last field is removed.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (r *RedisCache) HDel(key string, fields ...string) error {
+	defer r.stats.observe(common.OpDelete, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			r.fallback.journalDelete([]string{key}, nil, nil)
+			return r.fallback.HDel(key, fields...)
+		}
+		return r.degraded.unavailable(common.OpDelete)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpDelete, key, slog.Any("fields", fields))
+	removed, err := idempotent(ctx, r, r.client.HDel(ctx, key, fields...)).Result()
+	return r.stats.removed(removed, err)
+}
@@ -0,0 +1,93 @@
+package driver_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestRedisCache_HSet_HGet(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Store a user profile as a single record
+	_ = cache.Delete("user:1")
+	err := cache.HSet("user:1", map[string]string{"name": "alice", "email": "alice@example.com"})
+	if err != nil {
+		t.Errorf("Failed to set hash in cache: %v", err)
+	}
+
+	// Get a field from the cache
+	value, err := cache.HGet("user:1", "name")
+	if err != nil {
+		t.Errorf("Failed to get field from cache: %v", err)
+	}
+	if value != "alice" {
+		t.Errorf("Expected value %s, but got %s", "alice", value)
+	}
+
+	// Delete a field and get the whole record
+	err = cache.HDel("user:1", "email")
+	if err != nil {
+		t.Errorf("Failed to delete field from cache: %v", err)
+	}
+	fields, err := cache.HGetAll("user:1")
+	if err != nil {
+		t.Errorf("Failed to get hash from cache: %v", err)
+	}
+	if len(fields) != 1 || fields["name"] != "alice" {
+		t.Errorf("Expected hash with name only, but got %v", fields)
+	}
+}
+
+func TestRedisCache_HSetWithExpire(t *testing.T) {
+	// Create a Redis client for testing
+	client, server := newTestRedis(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Set a hash in the cache with an expiry time of 1 second
+	err := cache.HSetWithExpire("user:2", map[string]string{"name": "bob"}, 1)
+	if err != nil {
+		t.Errorf("Failed to set hash in cache with expiry: %v", err)
+	}
+
+	// Wait for the hash to expire
+	// Let 2 seconds elapse to ensure the hash has expired
+	server.fastForward(2 * time.Second)
+
+	// Check if the retrieved hash is empty
+	fields, err := cache.HGetAll("user:2")
+	if err != nil {
+		t.Errorf("Failed to get hash from cache: %v", err)
+	}
+	if len(fields) != 0 {
+		t.Errorf("Expected hash to be empty, but got %v", fields)
+	}
+}
+
+func TestRedisCache_HGet_UnavailableCache(t *testing.T) {
+	// Create a Redis client for testing
+	client := redis.NewClient(&redis.Options{
+		Addr: "invalid",
+	})
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Get a field from the cache
+	value, err := cache.HGet("user:1", "name")
+	if !errors.Is(err, driver.ErrMiss) {
+		t.Errorf("Expected a miss, but got %v", err)
+	}
+	if value != "" {
+		t.Errorf("Expected value to be empty, but got %s", value)
+	}
+}
@@ -0,0 +1,134 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/common"
+)
+
+// LPush inserts the values at the head of the Redis list stored under the given key, creating the list if needed.
+// It returns the length of the list after the push, or ErrNoValues if no values are given.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) LPush(key string, values ...string) (int64, error) {
+	defer r.stats.observe(common.OpSet, time.Now())
+	if len(values) == 0 {
+		return 0, ErrNoValues
+	}
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.LPush(key, values...)
+		}
+		return 0, r.degraded.unavailable(common.OpSet)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpSet, key)
+	length, err := r.client.LPush(ctx, key, toArgs(values)...).Result()
+	return length, r.stats.wrote(err)
+}
+
+// RPush appends the values at the tail of the Redis list stored under the given key, creating the list if needed.
+// It returns the length of the list after the push, or ErrNoValues if no values are given.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) RPush(key string, values ...string) (int64, error) {
+	defer r.stats.observe(common.OpSet, time.Now())
+	if len(values) == 0 {
+		return 0, ErrNoValues
+	}
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.RPush(key, values...)
+		}
+		return 0, r.degraded.unavailable(common.OpSet)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpSet, key)
+	length, err := r.client.RPush(ctx, key, toArgs(values)...).Result()
+	return length, r.stats.wrote(err)
+}
+
+// LPop removes and returns the first element of the Redis list stored under the given key.
+// If the list does not exist, it returns an empty string and ErrMiss.
+// If the cache is unavailable, it logs an error message and returns an empty string.
+func (r *RedisCache) LPop(key string) (string, error) {
+	defer r.stats.observe(common.OpDelete, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			r.fallback.journalDelete([]string{key}, nil, nil)
+			return r.fallback.LPop(key)
+		}
+		return "", r.degraded.unavailable(common.OpDelete)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpDelete, key)
+	return r.popped(r.client.LPop(ctx, key).Result())
+}
+
+// RPop removes and returns the last element of the Redis list stored under the given key.
+// If the list does not exist, it returns an empty string and ErrMiss.
+// If the cache is unavailable, it logs an error message and returns an empty string.
+func (r *RedisCache) RPop(key string) (string, error) {
+	defer r.stats.observe(common.OpDelete, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			r.fallback.journalDelete([]string{key}, nil, nil)
+			return r.fallback.RPop(key)
+		}
+		return "", r.degraded.unavailable(common.OpDelete)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpDelete, key)
+	return r.popped(r.client.RPop(ctx, key).Result())
+}
+
+// LRange returns the elements of the Redis list stored under the given key between the start and stop indexes,
+// inclusive. Negative indexes count from the tail of the list.
+// If the cache is unavailable, it logs an error message and returns an empty slice.
+func (r *RedisCache) LRange(key string, start int64, stop int64) ([]string, error) {
+	defer r.stats.observe(common.OpGet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.LRange(key, start, stop)
+		}
+		return []string{}, r.degraded.unavailable(common.OpGet)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpGet, key)
+	values, err := idempotent(ctx, r, r.client.LRange(ctx, key, start, stop)).Result()
+	return values, r.stats.lookup(len(values) > 0, err)
+}
+
+// LLen returns the length of the Redis list stored under the given key, or zero if it does not exist.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) LLen(key string) (int64, error) {
+	defer r.stats.observe(common.OpGet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.LLen(key)
+		}
+		return 0, r.degraded.unavailable(common.OpGet)
+	}
+	ctx := context.Background()
+	length, err := idempotent(ctx, r, r.client.LLen(ctx, key)).Result()
+	return length, r.stats.lookup(length > 0, err)
+}
+
+// popped counts the element returned by LPop or RPop as removed from the list, and turns the redis.Nil error
+// returned for a missing list into ErrMiss.
+func (r *RedisCache) popped(value string, err error) (string, error) {
+	if errors.Is(err, redis.Nil) {
+		return "", ErrMiss
+	}
+	return value, r.stats.removed(1, err)
+}
+
+// toArgs converts the values to the variadic interface arguments expected by go-redis.
+func toArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
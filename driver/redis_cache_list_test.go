@@ -0,0 +1,63 @@
+package driver_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestRedisCache_Push_Pop(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Push on both ends of the list
+	_ = cache.Delete("activity")
+	_, _ = cache.RPush("activity", "b", "c")
+	length, err := cache.LPush("activity", "a", "z")
+	if err != nil {
+		t.Errorf("Failed to push values to cache: %v", err)
+	}
+	if length != 4 {
+		t.Errorf("Expected length 4, but got %d", length)
+	}
+
+	// Check the order of the list
+	values, err := cache.LRange("activity", 0, -1)
+	if err != nil {
+		t.Errorf("Failed to get range from cache: %v", err)
+	}
+	expected := []string{"z", "a", "b", "c"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected range %v, but got %v", expected, values)
+	}
+
+	// Pop every value, then check that popping an empty list is a miss
+	for range expected {
+		_, _ = cache.RPop("activity")
+	}
+	value, err := cache.LPop("activity")
+	if value != "" || !errors.Is(err, driver.ErrMiss) {
+		t.Errorf("Expected empty value and ErrMiss, but got %q and %v", value, err)
+	}
+}
+
+func TestRedisCache_Push_NoValues(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Pushing nothing returns the same error as the memory driver
+	if _, err := cache.LPush("activity"); !errors.Is(err, driver.ErrNoValues) {
+		t.Errorf("Expected ErrNoValues, but got %v", err)
+	}
+	if _, err := cache.SAdd("members"); !errors.Is(err, driver.ErrNoValues) {
+		t.Errorf("Expected ErrNoValues, but got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package driver
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/sibeur/go-cache/common"
+)
+
+// SAdd adds the members to the Redis set stored under the given key, creating the set if needed.
+// It returns the number of members that were not already in the set, or ErrNoValues if no members are given.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) SAdd(key string, members ...string) (int64, error) {
+	defer r.stats.observe(common.OpSet, time.Now())
+	if len(members) == 0 {
+		return 0, ErrNoValues
+	}
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.SAdd(key, members...)
+		}
+		return 0, r.degraded.unavailable(common.OpSet)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpSet, key)
+	added, err := idempotent(ctx, r, r.client.SAdd(ctx, key, toArgs(members)...)).Result()
+	return added, r.stats.wrote(err)
+}
+
+// SRem removes the members from the Redis set stored under the given key and returns how many were removed.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) SRem(key string, members ...string) (int64, error) {
+	defer r.stats.observe(common.OpDelete, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			r.fallback.journalDelete([]string{key}, nil, nil)
+			return r.fallback.SRem(key, members...)
+		}
+		return 0, r.degraded.unavailable(common.OpDelete)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpDelete, key)
+	removed, err := idempotent(ctx, r, r.client.SRem(ctx, key, toArgs(members)...)).Result()
+	return removed, r.stats.removed(removed, err)
+}
+
+// SMembers returns the members of the Redis set stored under the given key,
+// sorted lexicographically like the memory driver does.
+// If the cache is unavailable, it logs an error message and returns an empty slice.
+func (r *RedisCache) SMembers(key string) ([]string, error) {
+	defer r.stats.observe(common.OpGet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.SMembers(key)
+		}
+		return []string{}, r.degraded.unavailable(common.OpGet)
+	}
+	ctx := context.Background()
+	members, err := idempotent(ctx, r, r.client.SMembers(ctx, key)).Result()
+	if err := r.stats.lookup(len(members) > 0, err); err != nil {
+		return nil, err
+	}
+	sort.Strings(members)
+	r.logger.operation(common.OpGet, key)
+	return members, nil
+}
+
+// SIsMember reports whether the member belongs to the Redis set stored under the given key.
+// If the cache is unavailable, it logs an error message and returns false.
+func (r *RedisCache) SIsMember(key string, member string) (bool, error) {
+	defer r.stats.observe(common.OpGet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.SIsMember(key, member)
+		}
+		return false, r.degraded.unavailable(common.OpGet)
+	}
+	ctx := context.Background()
+	ok, err := idempotent(ctx, r, r.client.SIsMember(ctx, key, member)).Result()
+	return ok, r.stats.lookup(ok, err)
+}
+
+// SCard returns the number of members of the Redis set stored under the given key, or zero if it does not exist.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) SCard(key string) (int64, error) {
+	defer r.stats.observe(common.OpGet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.SCard(key)
+		}
+		return 0, r.degraded.unavailable(common.OpGet)
+	}
+	ctx := context.Background()
+	card, err := idempotent(ctx, r, r.client.SCard(ctx, key)).Result()
+	return card, r.stats.lookup(card > 0, err)
+}
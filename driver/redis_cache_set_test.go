@@ -0,0 +1,42 @@
+package driver_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestRedisCache_SAdd_SRem(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	_ = cache.Delete("visitors")
+	added, err := cache.SAdd("visitors", "bob", "alice", "bob")
+	if err != nil {
+		t.Errorf("Failed to add members to cache: %v", err)
+	}
+	if added != 2 {
+		t.Errorf("Expected 2 members to be added, but got %d", added)
+	}
+
+	// Check that the members are sorted like in the memory driver
+	members, err := cache.SMembers("visitors")
+	if err != nil {
+		t.Errorf("Failed to get members from cache: %v", err)
+	}
+	if !reflect.DeepEqual(members, []string{"alice", "bob"}) {
+		t.Errorf("Expected members %v, but got %v", []string{"alice", "bob"}, members)
+	}
+
+	removed, _ := cache.SRem("visitors", "alice")
+	if removed != 1 {
+		t.Errorf("Expected 1 member to be removed, but got %d", removed)
+	}
+	if ok, _ := cache.SIsMember("visitors", "alice"); ok {
+		t.Errorf("Expected alice not to be a member, but it is")
+	}
+}
@@ -0,0 +1,197 @@
+package driver
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/common"
+)
+
+// tagKeyPrefix is prepended to a tag name to build the key of the sorted set holding its members.
+const tagKeyPrefix = "go-cache:tag:"
+
+// keyTagsPrefix is prepended to a key to build the key of the set holding its tags, the reverse index
+// used to remove the key from its tag sets when it is stored again or deleted.
+const keyTagsPrefix = "go-cache:keytags:"
+
+// internalKeyPattern matches every tag set and tag index, and is used to find them without walking the other keys
+// of the database.
+const internalKeyPattern = "go-cache:*"
+
+// isInternalKey reports whether key is one of the tag sets or tag indexes maintained by the Redis driver.
+// They are never reported, counted or deleted by the operations walking the keyspace.
+func isInternalKey(key string) bool {
+	return strings.HasPrefix(key, tagKeyPrefix) || strings.HasPrefix(key, keyTagsPrefix)
+}
+
+// untagLua defines the Lua functions shared by the tag scripts. serverNow returns the time of the Redis server
+// in milliseconds, so that the tag scores follow the clock the keys expire on. untag removes the key from the
+// sets of the tags listed in its index, then deletes the index. The tag keys are derived from the index,
+// so the scripts only run on a single Redis server, not on a cluster.
+const untagLua = `
+local function serverNow()
+	local t = redis.call('TIME')
+	return tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+end
+local function untag(key)
+	local index = '` + keyTagsPrefix + `' .. key
+	for _, tag in ipairs(redis.call('SMEMBERS', index)) do
+		redis.call('ZREM', '` + tagKeyPrefix + `' .. tag, key)
+	end
+	redis.call('DEL', index)
+end
+`
+
+// deleteScript unlinks the keys and removes them from their tags, returning how many keys existed.
+//
+// KEYS are the keys to delete.
+var deleteScript = redis.NewScript(untagLua + `
+local removed = 0
+for _, key in ipairs(KEYS) do
+	untag(key)
+	removed = removed + redis.call('UNLINK', key)
+end
+return removed
+`)
+
+// setWithTagsScript stores a value, replaces its previous tags with the given ones and adds its key to the
+// sorted set of every tag, scored by the expiration time of the value in milliseconds on the server clock
+// (+inf when it never expires). Members whose score is in the past belong to values Redis already expired,
+// so they are pruned on every write, and each tag set is given the expiration of its longest-living member
+// so that abandoned tags disappear on their own. The index of the tags of the key expires with the value.
+//
+// KEYS[1] is the value key and KEYS[2:] the tag keys. ARGV is the value, the TTL in milliseconds
+// and the tag names, in the order of their keys.
+var setWithTagsScript = redis.NewScript(untagLua + `
+local now = serverNow()
+local ttl = tonumber(ARGV[2])
+untag(KEYS[1])
+local score = '+inf'
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+	score = now + ttl
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+local index = '` + keyTagsPrefix + `' .. KEYS[1]
+for i = 2, #KEYS do
+	redis.call('ZREMRANGEBYSCORE', KEYS[i], '-inf', '(' .. now)
+	redis.call('ZADD', KEYS[i], score, KEYS[1])
+	redis.call('SADD', index, ARGV[i + 1])
+	local last = redis.call('ZRANGE', KEYS[i], -1, -1, 'WITHSCORES')
+	if last[2] == 'inf' then
+		redis.call('PERSIST', KEYS[i])
+	else
+		redis.call('PEXPIREAT', KEYS[i], last[2])
+	end
+end
+if #KEYS > 1 and ttl > 0 then
+	redis.call('PEXPIRE', index, ttl)
+end
+return 1
+`)
+
+// liveTagMembersScript returns the members of a tag set whose values still exist and still carry the tag.
+// A key stored again with Set keeps its stale memberships, since only its index is deleted: the index tells
+// them apart.
+//
+// KEYS[1] is the tag key. ARGV[1] is the tag name.
+var liveTagMembersScript = redis.NewScript(untagLua + `
+local live = {}
+for _, key in ipairs(redis.call('ZRANGEBYSCORE', KEYS[1], serverNow(), '+inf')) do
+	if redis.call('EXISTS', key) == 1 and redis.call('SISMEMBER', '` + keyTagsPrefix + `' .. key, ARGV[1]) == 1 then
+		table.insert(live, key)
+	end
+end
+return live
+`)
+
+// SetWithTags sets a key-value pair in the Redis cache and associates it with the given tags.
+// A TTL (in seconds) of zero stores the entry without expiration.
+// Storing the key again replaces its previous tags.
+// The value and its tag memberships are written atomically by a Lua script.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (r *RedisCache) SetWithTags(key string, value string, ttl uint64, tags ...string) error {
+	defer r.stats.observe(common.OpSet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.SetWithTags(key, value, ttl, tags...)
+		}
+		return r.degraded.unavailable(common.OpSet)
+	}
+	ctx := context.Background()
+	keys := make([]string, 0, len(tags)+1)
+	keys = append(keys, key)
+	args := make([]any, 0, len(tags)+2)
+	args = append(args, value, r.jitter.apply(ttl).Milliseconds())
+	for _, tag := range tags {
+		keys = append(keys, tagKeyPrefix+tag)
+		args = append(args, tag)
+	}
+	r.logger.operation(common.OpSet, key, slog.Uint64("ttl", ttl), r.logger.tags(tags))
+	return r.recordSet(r.runScript(ctx, setWithTagsScript, keys, args...).Err())
+}
+
+// InvalidateTags removes every entry associated with at least one of the given tags, together with the
+// tag sets themselves, and returns how many entries were removed.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) InvalidateTags(tags ...string) (int64, error) {
+	defer r.stats.observe(common.OpInvalidate, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			r.fallback.journalDelete(nil, nil, tags)
+			return r.fallback.InvalidateTags(tags...)
+		}
+		return 0, r.degraded.unavailable(common.OpInvalidate)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpInvalidate, "", r.logger.tags(tags))
+	return r.recordDelete(r.unlinkTags(ctx, tags))
+}
+
+// unlinkTags deletes the live members of the given tags in batches of scanBatchSize, then the tag sets themselves.
+func (r *RedisCache) unlinkTags(ctx context.Context, tags []string) (int64, error) {
+	var removed int64
+	for _, tag := range tags {
+		keys, err := r.liveTagMembers(ctx, tag)
+		if err != nil {
+			return removed, err
+		}
+		for start := 0; start < len(keys); start += scanBatchSize {
+			n, err := r.deleteKeys(ctx, keys[start:min(start+scanBatchSize, len(keys))])
+			removed += n
+			if err != nil {
+				return removed, err
+			}
+		}
+		if err := idempotent(ctx, r, r.client.Unlink(ctx, tagKeyPrefix+tag)).Err(); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// deleteKeys deletes the keys and removes them from their tags, returning how many keys existed.
+func (r *RedisCache) deleteKeys(ctx context.Context, keys []string) (int64, error) {
+	return r.runScript(ctx, deleteScript, keys).Int64()
+}
+
+// KeysByTag returns the keys of the live entries associated with the given tag.
+// If the cache is unavailable, it logs an error message and returns no keys.
+func (r *RedisCache) KeysByTag(tag string) ([]string, error) {
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.KeysByTag(tag)
+		}
+		return nil, r.degraded.unavailable(common.OpScan)
+	}
+	return r.liveTagMembers(context.Background(), tag)
+}
+
+// liveTagMembers returns the members of the tag set whose values have not expired yet.
+func (r *RedisCache) liveTagMembers(ctx context.Context, tag string) ([]string, error) {
+	return r.runScript(ctx, liveTagMembersScript, []string{tagKeyPrefix + tag}, tag).StringSlice()
+}
@@ -0,0 +1,149 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestRedisCache_InvalidateTags(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Cache pages of two products under unrelated keys
+	_ = cache.SetWithTags("page:home", "home", 0, "product:17", "product:18")
+	_ = cache.SetWithTags("page:product:17", "product 17", 0, "product:17")
+	_ = cache.SetWithTags("page:product:18", "product 18", 60, "product:18")
+
+	// Invalidate everything touching product 17
+	removed, err := cache.InvalidateTags("product:17")
+	if err != nil {
+		t.Errorf("Failed to invalidate tags: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 keys to be removed, but got %d", removed)
+	}
+
+	// Check that the pages of product 17 are deleted
+	for _, key := range []string{"page:home", "page:product:17"} {
+		value, err := cache.Get(key)
+		if err != nil && !errors.Is(err, driver.ErrMiss) {
+			t.Errorf("Failed to get value from cache: %v", err)
+		}
+		if value != "" {
+			t.Errorf("Expected value of %s to be empty, but got %s", key, value)
+		}
+	}
+
+	// Check that the page of product 18 is kept
+	value, err := cache.Get("page:product:18")
+	if err != nil {
+		t.Errorf("Failed to get value from cache: %v", err)
+	}
+	if value != "product 18" {
+		t.Errorf("Expected value %s, but got %s", "product 18", value)
+	}
+}
+
+func TestRedisCache_SetWithTags_Expire(t *testing.T) {
+	// Create a Redis client for testing
+	client, server := newTestRedis(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Start from an empty tag
+	_, _ = cache.InvalidateTags("expiring")
+	err := cache.SetWithTags("tagged", "value1", 1, "expiring")
+	if err != nil {
+		t.Errorf("Failed to set value with tags: %v", err)
+	}
+
+	keys, err := cache.KeysByTag("expiring")
+	if err != nil {
+		t.Errorf("Failed to get keys by tag: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Errorf("Expected tag to hold 1 key, but got %v", keys)
+	}
+
+	// Let 2 seconds elapse on the server to ensure the value has expired
+	server.fastForward(2 * time.Second)
+
+	// Check that the expired key is no longer reported under its tag
+	keys, err = cache.KeysByTag("expiring")
+	if err != nil {
+		t.Errorf("Failed to get keys by tag: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Expected tag to be empty, but got %v", keys)
+	}
+
+	// Check that a new write prunes the dangling member from the tag set
+	_ = cache.SetWithTags("tagged2", "value2", 0, "expiring")
+	members, err := client.ZRange(context.Background(), "go-cache:tag:expiring", 0, -1).Result()
+	if err != nil {
+		t.Errorf("Failed to read tag set: %v", err)
+	}
+	if len(members) != 1 || members[0] != "tagged2" {
+		t.Errorf("Expected tag set to hold tagged2 only, but got %v", members)
+	}
+}
+
+func TestRedisCache_InvalidateTags_UnavailableCache(t *testing.T) {
+	// Create a Redis client for testing
+	client := redis.NewClient(&redis.Options{
+		Addr: "invalid",
+	})
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Invalidate a tag
+	removed, err := cache.InvalidateTags("tag1")
+	if err != nil {
+		t.Errorf("Failed to invalidate tags: %v", err)
+	}
+	if removed != 0 {
+		t.Errorf("Expected 0 keys to be removed, but got %d", removed)
+	}
+}
+
+func TestRedisCache_SetWithTags_InternalKeysHidden(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+	_ = cache.Flush()
+	_ = cache.SetWithTags("key1", "value1", 0, "product:17")
+	ctx := context.Background()
+
+	// The tag set and the tag index are neither counted nor scanned
+	if n, err := cache.Len(ctx); n != 1 || err != nil {
+		t.Errorf("Expected 1 key and no error, but got %d and %v", n, err)
+	}
+	if items := cache.Stats().Items; items != 1 {
+		t.Errorf("Expected 1 item, but got %d", items)
+	}
+	var keys []string
+	_ = cache.Scan(ctx, "*", func(key string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) != 1 || keys[0] != "key1" {
+		t.Errorf("Expected key1 only, but got %v", keys)
+	}
+
+	// Nor deleted by a pattern
+	if removed, err := cache.DeleteByPattern(ctx, "*"); removed != 1 || err != nil {
+		t.Errorf("Expected 1 key to be removed and no error, but got %d and %v", removed, err)
+	}
+}
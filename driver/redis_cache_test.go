@@ -1,6 +1,9 @@
 package driver_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -10,9 +13,7 @@ import (
 
 func TestRedisCache_Get(t *testing.T) {
 	// Create a Redis client for testing
-	client := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})
+	client := newTestRedisClient(t)
 
 	// Create a RedisCache instance
 	cache := driver.NewRedisCache(client)
@@ -25,7 +26,7 @@ func TestRedisCache_Get(t *testing.T) {
 
 	// Get the value from the cache
 	value, err := cache.Get("key")
-	if err != nil && err != redis.Nil {
+	if err != nil && !errors.Is(err, driver.ErrMiss) {
 		t.Errorf("Failed to get value from cache: %v", err)
 	}
 
@@ -38,9 +39,7 @@ func TestRedisCache_Get(t *testing.T) {
 
 func TestRedisCache_Set(t *testing.T) {
 	// Create a Redis client for testing
-	client := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})
+	client := newTestRedisClient(t)
 
 	// Create a RedisCache instance
 	cache := driver.NewRedisCache(client)
@@ -53,7 +52,7 @@ func TestRedisCache_Set(t *testing.T) {
 
 	// Get the value from the cache
 	value, err := cache.Get("key")
-	if err != nil && err != redis.Nil {
+	if err != nil && !errors.Is(err, driver.ErrMiss) {
 		t.Errorf("Failed to get value from cache: %v", err)
 	}
 
@@ -66,9 +65,7 @@ func TestRedisCache_Set(t *testing.T) {
 
 func TestRedisCache_SetWithExpire(t *testing.T) {
 	// Create a Redis client for testing
-	client := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})
+	client, server := newTestRedis(t)
 
 	// Create a RedisCache instance
 	cache := driver.NewRedisCache(client)
@@ -82,12 +79,12 @@ func TestRedisCache_SetWithExpire(t *testing.T) {
 	}
 
 	// Wait for the value to expire
-	// Sleep for 2 seconds to ensure the value has expired
-	time.Sleep(2 * time.Second)
+	// Let 2 seconds elapse to ensure the value has expired
+	server.fastForward(2 * time.Second)
 
 	// Get the value from the cache
 	value, err = cache.Get(key)
-	if err != nil && err != redis.Nil {
+	if err != nil && !errors.Is(err, driver.ErrMiss) {
 		t.Errorf("Failed to get value from cache: %v", err)
 	}
 
@@ -99,9 +96,7 @@ func TestRedisCache_SetWithExpire(t *testing.T) {
 
 func TestRedisCache_Delete(t *testing.T) {
 	// Create a Redis client for testing
-	client := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})
+	client := newTestRedisClient(t)
 
 	// Create a RedisCache instance
 	cache := driver.NewRedisCache(client)
@@ -120,7 +115,7 @@ func TestRedisCache_Delete(t *testing.T) {
 
 	// Get the value from the cache
 	value, err := cache.Get("key")
-	if err != nil && err != redis.Nil {
+	if err != nil && !errors.Is(err, driver.ErrMiss) {
 		t.Errorf("Failed to get value from cache: %v", err)
 	}
 
@@ -132,9 +127,7 @@ func TestRedisCache_Delete(t *testing.T) {
 
 func TestRedisCache_Flush(t *testing.T) {
 	// Create a Redis client for testing
-	client := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})
+	client := newTestRedisClient(t)
 
 	// Create a RedisCache instance
 	cache := driver.NewRedisCache(client)
@@ -153,7 +146,7 @@ func TestRedisCache_Flush(t *testing.T) {
 
 	// Get the value from the cache
 	value, err := cache.Get("key")
-	if err != nil && err != redis.Nil {
+	if err != nil && !errors.Is(err, driver.ErrMiss) {
 		t.Errorf("Failed to get value from cache: %v", err)
 	}
 
@@ -165,9 +158,7 @@ func TestRedisCache_Flush(t *testing.T) {
 
 func TestRedisCache_IsCacheAvailable(t *testing.T) {
 	// Create a Redis client for testing
-	client := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})
+	client := newTestRedisClient(t)
 
 	// Create a RedisCache instance
 	cache := driver.NewRedisCache(client)
@@ -180,9 +171,7 @@ func TestRedisCache_IsCacheAvailable(t *testing.T) {
 
 func TestRedisCache_SetCacheAvailable(t *testing.T) {
 	// Create a Redis client for testing
-	client := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})
+	client := newTestRedisClient(t)
 
 	// Create a RedisCache instance
 	cache := driver.NewRedisCache(client)
@@ -198,9 +187,7 @@ func TestRedisCache_SetCacheAvailable(t *testing.T) {
 
 func TestRedisCache_GetDriverName(t *testing.T) {
 	// Create a Redis client for testing
-	client := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})
+	client := newTestRedisClient(t)
 
 	// Create a RedisCache instance
 	cache := driver.NewRedisCache(client)
@@ -242,8 +229,8 @@ func TestRedisCache_Get_UnavailableCache(t *testing.T) {
 
 	// Get the value from the cache
 	value, err := cache.Get("key")
-	if err != nil {
-		t.Errorf("Failed to get value from cache: %v", err)
+	if !errors.Is(err, driver.ErrMiss) {
+		t.Errorf("Expected a miss, but got %v", err)
 	}
 
 	// Check if the retrieved value is empty
@@ -275,8 +262,8 @@ func TestRedisCache_SetWithExpire_UnavailableCache(t *testing.T) {
 
 	// Get the value from the cache
 	value, err = cache.Get(key)
-	if err != nil {
-		t.Errorf("Failed to get value from cache: %v", err)
+	if !errors.Is(err, driver.ErrMiss) {
+		t.Errorf("Expected a miss, but got %v", err)
 	}
 
 	// Check if the retrieved value is empty
@@ -350,3 +337,133 @@ func TestRedisCache_IsCacheAvailable_UnavailableCache(t *testing.T) {
 		t.Errorf("Expected cache to be unavailable, but got available")
 	}
 }
+
+func TestRedisCache_Scan(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Start from an empty database and set values under two prefixes
+	_ = cache.Flush()
+	_ = cache.Set("user:1", "alice")
+	_ = cache.Set("user:2", "bob")
+	_ = cache.Set("order:1", "book")
+
+	keys := map[string]bool{}
+	err := cache.Scan(context.Background(), "user:*", func(key string) bool {
+		keys[key] = true
+		return true
+	})
+	if err != nil {
+		t.Errorf("Failed to scan cache: %v", err)
+	}
+
+	// Check that only the user keys were scanned
+	if len(keys) != 2 || !keys["user:1"] || !keys["user:2"] {
+		t.Errorf("Expected keys user:1 and user:2, but got %v", keys)
+	}
+}
+
+func TestRedisCache_Len(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Start from an empty database and set two values
+	_ = cache.Flush()
+	_ = cache.Set("key1", "value1")
+	_ = cache.Set("key2", "value2")
+
+	count, err := cache.Len(context.Background())
+	if err != nil {
+		t.Errorf("Failed to count cache entries: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 entries, but got %d", count)
+	}
+}
+
+func TestRedisCache_Scan_UnavailableCache(t *testing.T) {
+	// Create a Redis client for testing
+	client := redis.NewClient(&redis.Options{
+		Addr: "invalid",
+	})
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Scan the cache
+	err := cache.Scan(context.Background(), "*", func(key string) bool {
+		t.Errorf("Expected no keys, but got %s", key)
+		return true
+	})
+	if err != nil {
+		t.Errorf("Failed to scan cache: %v", err)
+	}
+}
+
+func TestRedisCache_DeleteByPattern(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Set values for two tenants
+	_ = cache.Set("tenant:42:user", "alice")
+	_ = cache.Set("tenant:42:order", "book")
+	_ = cache.Set("tenant:43:user", "bob")
+
+	// Delete every key of tenant 42
+	removed, err := cache.DeleteByPattern(context.Background(), "tenant:42:*")
+	if err != nil {
+		t.Errorf("Failed to delete by pattern: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 keys to be removed, but got %d", removed)
+	}
+
+	// Check that the keys of tenant 43 are still there
+	value, err := cache.Get("tenant:43:user")
+	if err != nil {
+		t.Errorf("Failed to get value from cache: %v", err)
+	}
+	if value != "bob" {
+		t.Errorf("Expected value %s, but got %s", "bob", value)
+	}
+}
+
+func TestRedisCache_DeleteByPrefix(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	// Set more values than a single UNLINK batch holds
+	for i := 0; i < 250; i++ {
+		_ = cache.Set(fmt.Sprintf("prefix:%d", i), "value")
+	}
+	_ = cache.Set("prefix*", "literal")
+
+	// The prefix must be matched literally
+	removed, err := cache.DeleteByPrefix(context.Background(), "prefix*")
+	if err != nil {
+		t.Errorf("Failed to delete by prefix: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 key to be removed, but got %d", removed)
+	}
+
+	removed, err = cache.DeleteByPrefix(context.Background(), "prefix:")
+	if err != nil {
+		t.Errorf("Failed to delete by prefix: %v", err)
+	}
+	if removed != 250 {
+		t.Errorf("Expected 250 keys to be removed, but got %d", removed)
+	}
+}
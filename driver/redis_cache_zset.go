@@ -0,0 +1,147 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/common"
+)
+
+// ZAdd adds the member with the given score to the Redis sorted set stored under the given key,
+// creating the set if needed. The score of an existing member is updated.
+// If the cache is unavailable, it logs an error message and returns nil.
+func (r *RedisCache) ZAdd(key string, member string, score float64) error {
+	defer r.stats.observe(common.OpSet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.ZAdd(key, member, score)
+		}
+		return r.degraded.unavailable(common.OpSet)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpSet, key)
+	return r.stats.wrote(idempotent(ctx, r, r.client.ZAdd(ctx, key, redis.Z{Score: score, Member: member})).Err())
+}
+
+// ZIncrBy increments the score of the member of the Redis sorted set stored under the given key,
+// adding the member with the increment as its score if it does not exist yet. It returns the new score.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) ZIncrBy(key string, member string, increment float64) (float64, error) {
+	defer r.stats.observe(common.OpSet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.ZIncrBy(key, member, increment)
+		}
+		return 0, r.degraded.unavailable(common.OpSet)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpSet, key)
+	score, err := r.client.ZIncrBy(ctx, key, increment, member).Result()
+	return score, r.stats.wrote(err)
+}
+
+// ZScore returns the score of the member of the Redis sorted set stored under the given key.
+// The boolean result is false if the key or the member does not exist.
+// If the cache is unavailable, it logs an error message and returns false.
+func (r *RedisCache) ZScore(key string, member string) (float64, bool, error) {
+	defer r.stats.observe(common.OpGet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.ZScore(key, member)
+		}
+		return 0, false, r.degraded.unavailable(common.OpGet)
+	}
+	ctx := context.Background()
+	score, err := idempotent(ctx, r, r.client.ZScore(ctx, key, member)).Result()
+	if errors.Is(err, redis.Nil) {
+		r.stats.read(false)
+		return 0, false, nil
+	}
+	if err := r.stats.lookup(true, err); err != nil {
+		return 0, false, err
+	}
+	return score, true, nil
+}
+
+// ZRem removes the members from the Redis sorted set stored under the given key and returns how many were removed.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) ZRem(key string, members ...string) (int64, error) {
+	defer r.stats.observe(common.OpDelete, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			r.fallback.journalDelete([]string{key}, nil, nil)
+			return r.fallback.ZRem(key, members...)
+		}
+		return 0, r.degraded.unavailable(common.OpDelete)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpDelete, key)
+	removed, err := idempotent(ctx, r, r.client.ZRem(ctx, key, toArgs(members)...)).Result()
+	return removed, r.stats.removed(removed, err)
+}
+
+// ZRange returns the members of the Redis sorted set stored under the given key between the start and stop ranks,
+// inclusive, ordered from the lowest to the highest score.
+// If the cache is unavailable, it logs an error message and returns an empty slice.
+func (r *RedisCache) ZRange(key string, start int64, stop int64) ([]ScoredMember, error) {
+	defer r.stats.observe(common.OpGet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.ZRange(key, start, stop)
+		}
+		return []ScoredMember{}, r.degraded.unavailable(common.OpGet)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpGet, key)
+	return r.rangeRead(toScoredMembers(idempotent(ctx, r, r.client.ZRangeWithScores(ctx, key, start, stop)).Result()))
+}
+
+// ZRevRange returns the members of the Redis sorted set stored under the given key between the start and stop ranks,
+// inclusive, ordered from the highest to the lowest score.
+// If the cache is unavailable, it logs an error message and returns an empty slice.
+func (r *RedisCache) ZRevRange(key string, start int64, stop int64) ([]ScoredMember, error) {
+	defer r.stats.observe(common.OpGet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.ZRevRange(key, start, stop)
+		}
+		return []ScoredMember{}, r.degraded.unavailable(common.OpGet)
+	}
+	ctx := context.Background()
+	r.logger.operation(common.OpGet, key)
+	return r.rangeRead(toScoredMembers(idempotent(ctx, r, r.client.ZRevRangeWithScores(ctx, key, start, stop)).Result()))
+}
+
+// ZCard returns the number of members of the Redis sorted set stored under the given key, or zero if it does not exist.
+// If the cache is unavailable, it logs an error message and returns zero.
+func (r *RedisCache) ZCard(key string) (int64, error) {
+	defer r.stats.observe(common.OpGet, time.Now())
+	if !r.isAvailable.Load() {
+		if r.fallback != nil {
+			return r.fallback.ZCard(key)
+		}
+		return 0, r.degraded.unavailable(common.OpGet)
+	}
+	ctx := context.Background()
+	card, err := idempotent(ctx, r, r.client.ZCard(ctx, key)).Result()
+	return card, r.stats.lookup(card > 0, err)
+}
+
+// rangeRead counts a range query of a sorted set as a hit if it returned members.
+func (r *RedisCache) rangeRead(members []ScoredMember, err error) ([]ScoredMember, error) {
+	return members, r.stats.lookup(len(members) > 0, err)
+}
+
+// toScoredMembers converts the go-redis sorted set entries to ScoredMember values.
+func toScoredMembers(entries []redis.Z, err error) ([]ScoredMember, error) {
+	if err != nil {
+		return nil, err
+	}
+	members := make([]ScoredMember, len(entries))
+	for i, entry := range entries {
+		members[i] = ScoredMember{Member: entry.Member.(string), Score: entry.Score}
+	}
+	return members, nil
+}
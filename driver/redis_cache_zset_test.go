@@ -0,0 +1,38 @@
+package driver_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestRedisCache_Leaderboard(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	_ = cache.Delete("leaderboard")
+	_ = cache.ZAdd("leaderboard", "alice", 10)
+	_ = cache.ZAdd("leaderboard", "bob", 20)
+	_ = cache.ZAdd("leaderboard", "carol", 10)
+	_, _ = cache.ZIncrBy("leaderboard", "alice", 15)
+
+	// Top two players, highest score first
+	top, err := cache.ZRevRange("leaderboard", 0, 1)
+	if err != nil {
+		t.Errorf("Failed to get range from cache: %v", err)
+	}
+	expected := []driver.ScoredMember{{Member: "alice", Score: 25}, {Member: "bob", Score: 20}}
+	if !reflect.DeepEqual(top, expected) {
+		t.Errorf("Expected range %v, but got %v", expected, top)
+	}
+
+	// Check that a missing member is reported as not found
+	_, ok, err := cache.ZScore("leaderboard", "dave")
+	if err != nil || ok {
+		t.Errorf("Expected member to be missing, but got found %v and error %v", ok, err)
+	}
+}
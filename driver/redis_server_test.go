@@ -0,0 +1,52 @@
+package driver_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/cachetest"
+)
+
+// testRedis is the Redis server a test runs against.
+type testRedis struct {
+	fake *miniredis.Miniredis // The in-process fake server, nil when testing against a real server.
+	now  time.Time            // The clock of the fake server, zero until it is first moved forward.
+}
+
+// newTestRedis returns a client connected to the Redis server of the test, together with the server.
+// The client is closed and the fake server stopped when the test ends.
+func newTestRedis(t *testing.T) (*redis.Client, *testRedis) {
+	t.Helper()
+	server := &testRedis{}
+	var addr string
+	addr, server.fake = cachetest.RedisServer(t)
+	client := redis.NewClient(&redis.Options{
+		Addr: addr,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+	return client, server
+}
+
+// newTestRedisClient returns a client connected to the Redis server of the test, see newTestRedis.
+func newTestRedisClient(t *testing.T) *redis.Client {
+	t.Helper()
+	client, _ := newTestRedis(t)
+	return client
+}
+
+// fastForward lets the given duration elapse on the server, so that the keys whose TTL is shorter expire.
+// The clock and the TTLs of the fake server are moved forward instantly, while a real server is waited for.
+func (s *testRedis) fastForward(d time.Duration) {
+	if s.fake != nil {
+		if s.now.IsZero() {
+			s.now = time.Now()
+		}
+		s.now = s.now.Add(d)
+		s.fake.SetTime(s.now)
+		s.fake.FastForward(d)
+		return
+	}
+	time.Sleep(d)
+}
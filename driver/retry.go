@@ -0,0 +1,150 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// RetryPolicy configures how the Redis driver retries the commands that fail with a transient error.
+type RetryPolicy struct {
+	MaxAttempts    int                  // Maximum number of attempts of a command, including the first one. One or less disables retries.
+	InitialBackoff time.Duration        // Wait before the first retry, doubled before every following one.
+	MaxBackoff     time.Duration        // Upper bound of the wait between two attempts, zero for no bound.
+	Jitter         float64              // Share of every wait, between 0 and 1, that is randomly cut off to spread the retries.
+	Retryable      func(err error) bool // Reports whether a failed command is retried. It defaults to IsRetryable.
+}
+
+// DefaultRetryPolicy makes up to three attempts, waiting around 50ms then 100ms between them.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 50 * time.Millisecond,
+	MaxBackoff:     time.Second,
+	Jitter:         0.5,
+}
+
+// retryableReplyPrefixes are the prefixes of the Redis error replies that describe a transient server state.
+var retryableReplyPrefixes = []string{"LOADING", "BUSY", "TRYAGAIN", "CLUSTERDOWN", "MASTERDOWN"}
+
+// IsRetryable reports whether err is a transient error worth retrying: a timeout or another network error,
+// a connection closed by the server, or a Redis reply such as LOADING or BUSY telling that the server cannot
+// answer yet. Misses (redis.Nil), command errors such as WRONGTYPE, a closed client and a canceled or expired
+// context are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, redis.ErrClosed) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var replyErr redis.Error
+	if errors.As(err, &replyErr) {
+		msg := replyErr.Error()
+		for _, prefix := range retryableReplyPrefixes {
+			if strings.HasPrefix(msg, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the given retry, starting at 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || wait < p.MaxBackoff); i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 && wait > 0 {
+		wait -= time.Duration(rand.Float64() * jitter * float64(wait))
+	}
+	return wait
+}
+
+// retrier retries the idempotent commands of a RedisCache according to a RetryPolicy.
+type retrier struct {
+	policy   RetryPolicy
+	attempts int // The attempts made by the driver, each of which go-redis may still retry on its own.
+	logger   *cacheLogger
+	stats    *statsRecorder
+}
+
+// newRetrier creates the retrier applying policy on top of the retries the client makes on its own, counting its
+// retries in stats. It returns nil if the policy makes a single attempt.
+func newRetrier(policy RetryPolicy, logger *cacheLogger, stats *statsRecorder) *retrier {
+	if policy.MaxAttempts <= 1 {
+		return nil
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = IsRetryable
+	}
+	return &retrier{policy: policy, attempts: policy.MaxAttempts, logger: logger, stats: stats}
+}
+
+// idempotent returns cmd once it succeeded or failed for good, sending it again with the retry policy of the cache
+// while it fails with a retryable error. Only the commands that can safely be applied twice go through it:
+// a timeout may hide the success of the first attempt.
+func idempotent[C redis.Cmder](ctx context.Context, r *RedisCache, cmd C) C {
+	if r.retrier != nil {
+		_ = r.retrier.do(ctx, cmd.Name(), cmd.Err(), func() error {
+			cmd.SetErr(nil)
+			return r.client.Process(ctx, cmd)
+		})
+	}
+	return cmd
+}
+
+// runScript runs the idempotent script, retrying it as idempotent retries a command. The whole script is run again,
+// since its EVALSHA may have failed before Redis loaded it.
+func (r *RedisCache) runScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) *redis.Cmd {
+	var cmd *redis.Cmd
+	_ = r.retryIdempotent(ctx, "evalsha", func() error {
+		cmd = script.Run(ctx, r.client, keys, args...)
+		return cmd.Err()
+	})
+	return cmd
+}
+
+// retryIdempotent runs the idempotent attempt, such as a transaction, as idempotent sends a single command.
+func (r *RedisCache) retryIdempotent(ctx context.Context, name string, attempt func() error) error {
+	err := attempt()
+	if r.retrier == nil {
+		return err
+	}
+	return r.retrier.do(ctx, name, err, attempt)
+}
+
+// do runs attempt again while err is retryable, until it succeeds or runs out of attempts, and returns the last error.
+// It gives up early when the context is done, or when its deadline would expire before the next attempt.
+func (h *retrier) do(ctx context.Context, name string, err error, attempt func() error) error {
+	for retry := 1; retry < h.attempts && err != nil && h.policy.Retryable(err); retry++ {
+		wait := h.policy.backoff(retry)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return err
+		}
+		h.logger.warn("retrying redis command", slog.String("command", name), slog.Int("retry", retry),
+			slog.Duration("backoff", wait), slog.Any("error", err))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		h.stats.retries.Add(1)
+		err = attempt()
+	}
+	return err
+}
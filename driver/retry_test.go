@@ -0,0 +1,215 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache/driver"
+)
+
+// replyError is an error reply of the Redis server.
+type replyError string
+
+func (e replyError) Error() string { return string(e) }
+
+func (replyError) RedisError() {}
+
+// newRetryingCache returns a Redis cache retrying with the policy, together with its fake server.
+// The client does not retry on its own, so that every retry comes from the policy.
+// The test is skipped when it runs against a real server, whose errors cannot be injected.
+func newRetryingCache(t *testing.T, policy driver.RetryPolicy) (*driver.RedisCache, *testRedis) {
+	t.Helper()
+	_, server := newTestRedis(t)
+	if server.fake == nil {
+		t.Skip("errors can only be injected into the fake server")
+	}
+	client := redis.NewClient(&redis.Options{Addr: server.fake.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+	return driver.NewRedisCache(client, driver.WithRetry(policy)), server
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"timeout", &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, true},
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, true},
+		{"eof", io.EOF, true},
+		{"loading", replyError("LOADING Redis is loading the dataset in memory"), true},
+		{"miss", redis.Nil, false},
+		{"wrong type", replyError("WRONGTYPE Operation against a key holding the wrong kind of value"), false},
+		{"closed client", redis.ErrClosed, false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"canceled", context.Canceled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := driver.IsRetryable(tt.err); got != tt.want {
+				t.Errorf("Expected IsRetryable(%v) to be %v, but got %v", tt.err, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRedisCache_RetryTransientError(t *testing.T) {
+	cache, server := newRetryingCache(t, driver.RetryPolicy{MaxAttempts: 10, InitialBackoff: 10 * time.Millisecond})
+
+	// The server answers again while the command is being retried
+	server.fake.SetError("LOADING Redis is loading the dataset in memory")
+	time.AfterFunc(50*time.Millisecond, func() { server.fake.SetError("") })
+
+	if err := cache.Set("key1", "value1"); err != nil {
+		t.Fatalf("Expected the write to succeed after retries, but got %v", err)
+	}
+	if value, _ := cache.Get("key1"); value != "value1" {
+		t.Errorf("Expected value1, but got %q", value)
+	}
+	if retries := cache.Stats().Retries; retries == 0 {
+		t.Error("Expected the retries to be counted")
+	}
+}
+
+func TestRedisCache_RetryGivesUp(t *testing.T) {
+	cache, server := newRetryingCache(t, driver.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	server.fake.SetError("LOADING Redis is loading the dataset in memory")
+	if err := cache.Set("key1", "value1"); err == nil {
+		t.Fatal("Expected an error once the attempts are exhausted")
+	}
+	server.fake.SetError("")
+	if retries := cache.Stats().Retries; retries != 2 {
+		t.Errorf("Expected 2 retries, but got %d", retries)
+	}
+}
+
+func TestRedisCache_RetrySkipsPermanentErrors(t *testing.T) {
+	cache, _ := newRetryingCache(t, driver.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	// Misses and wrong types are answered at once
+	if _, err := cache.Get("missing"); err != nil && !errors.Is(err, driver.ErrMiss) {
+		t.Fatalf("Expected a miss, but got %v", err)
+	}
+	if _, err := cache.LPush("list", "value"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if _, err := cache.Get("list"); err == nil {
+		t.Fatal("Expected a WRONGTYPE error")
+	}
+	if retries := cache.Stats().Retries; retries != 0 {
+		t.Errorf("Expected no retries, but got %d", retries)
+	}
+}
+
+func TestRedisCache_RetryRespectsDeadline(t *testing.T) {
+	cache, server := newRetryingCache(t, driver.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second})
+
+	// The backoff does not fit before the deadline, so the error is returned without waiting
+	server.fake.SetError("LOADING Redis is loading the dataset in memory")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := cache.Len(ctx); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected the retries to stop before the deadline, but they took %v", elapsed)
+	}
+	server.fake.SetError("")
+	if retries := cache.Stats().Retries; retries != 0 {
+		t.Errorf("Expected no retries, but got %d", retries)
+	}
+}
+
+// countingHook is a go-redis hook counting the commands sent by a client.
+type countingHook struct {
+	commands *atomic.Int32
+}
+
+func (h countingHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h countingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.commands.Add(1)
+		return next(ctx, cmd)
+	}
+}
+
+func (h countingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestRedisCache_RetrySkipsNonIdempotentCommands(t *testing.T) {
+	cache, server := newRetryingCache(t, driver.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	// A push could be applied twice if its success was hidden, so it is sent once
+	server.fake.SetError("LOADING Redis is loading the dataset in memory")
+	if _, err := cache.LPush("list", "value"); err == nil {
+		t.Fatal("Expected the error of the single attempt")
+	}
+	if _, err := cache.ZIncrBy("zset", "member", 1); err == nil {
+		t.Fatal("Expected the error of the single attempt")
+	}
+	server.fake.SetError("")
+	if retries := cache.Stats().Retries; retries != 0 {
+		t.Errorf("Expected no retries, but got %d", retries)
+	}
+}
+
+func TestRedisCache_RetryLeavesClientAlone(t *testing.T) {
+	_, server := newTestRedis(t)
+	if server.fake == nil {
+		t.Skip("errors can only be injected into the fake server")
+	}
+	client := redis.NewClient(&redis.Options{Addr: server.fake.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+	policy := driver.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	cache := driver.NewRedisCache(client, driver.WithRetry(policy))
+	_ = driver.NewRedisCache(client, driver.WithRetry(policy))
+	var commands atomic.Int32
+	client.AddHook(countingHook{commands: &commands})
+
+	// Creating several drivers over a client does not stack their retries
+	server.fake.SetError("LOADING Redis is loading the dataset in memory")
+	_, _ = cache.Get("key1")
+	server.fake.SetError("")
+	if sent := commands.Load(); sent != 3 {
+		t.Errorf("Expected 3 attempts, but got %d", sent)
+	}
+
+	// The other users of the client are not retried
+	commands.Store(0)
+	server.fake.SetError("LOADING Redis is loading the dataset in memory")
+	_ = client.Get(context.Background(), "key1").Err()
+	server.fake.SetError("")
+	if sent := commands.Load(); sent != 1 {
+		t.Errorf("Expected a single attempt, but got %d", sent)
+	}
+}
+
+func TestRedisCache_RetryOverClientRetries(t *testing.T) {
+	_, server := newTestRedis(t)
+	if server.fake == nil {
+		t.Skip("errors can only be injected into the fake server")
+	}
+	// The client keeps its default retries, and the policy still makes all of its attempts on top of them
+	client := redis.NewClient(&redis.Options{Addr: server.fake.Addr(), MinRetryBackoff: -1})
+	t.Cleanup(func() { _ = client.Close() })
+	cache := driver.NewRedisCache(client, driver.WithRetry(driver.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+	server.fake.SetError("LOADING Redis is loading the dataset in memory")
+	_, _ = cache.Get("key1")
+	server.fake.SetError("")
+	if retries := cache.Stats().Retries; retries != 2 {
+		t.Errorf("Expected 2 retries, but got %d", retries)
+	}
+}
@@ -0,0 +1,24 @@
+package driver
+
+import "sort"
+
+// ScoredMember is a member of a sorted set together with its score.
+type ScoredMember struct {
+	Member string  // The member of the sorted set.
+	Score  float64 // The score the set is ordered by.
+}
+
+// sortScoredMembers orders the members by ascending score, breaking ties lexicographically by member,
+// which is the order Redis keeps sorted sets in. With reverse set, the order is fully inverted.
+func sortScoredMembers(members []ScoredMember, reverse bool) {
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		if reverse {
+			a, b = b, a
+		}
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return a.Member < b.Member
+	})
+}
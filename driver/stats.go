@@ -0,0 +1,198 @@
+package driver
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/sibeur/go-cache/common"
+)
+
+// latencyBounds are the upper bounds of the buckets of the latency histograms.
+var latencyBounds = []time.Duration{
+	time.Microsecond,
+	5 * time.Microsecond,
+	10 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// trackedOperations are the operations a latency histogram is kept for.
+var trackedOperations = []string{
+	common.OpGet,
+	common.OpSet,
+	common.OpDelete,
+	common.OpFlush,
+	common.OpScan,
+	common.OpInvalidate,
+}
+
+// Stats is a snapshot of the statistics of a cache driver.
+// The hashes, lists, sets and sorted sets are counted too: their reads as hits or misses, every write
+// as one set and every field, element or member they lose as one deletion.
+type Stats struct {
+	Hits        uint64                      // Number of reads that found a value.
+	Misses      uint64                      // Number of reads that found no value.
+	Sets        uint64                      // Number of values written.
+	Deletes     uint64                      // Number of values deleted, including pattern, prefix and tag deletions.
+	Evictions   uint64                      // Number of values removed to make room for others.
+	Expirations uint64                      // Number of values removed because their TTL elapsed.
+	Errors      uint64                      // Number of operations that returned an error.
+	Unavailable uint64                      // Number of operations skipped or refused because the cache was unavailable.
+	Retries     uint64                      // Number of commands sent again after a transient error.
+	Items       int64                       // Number of values currently stored.
+	Latencies   map[string]LatencyHistogram // Latency histogram of every operation, keyed by operation name.
+}
+
+// HitRatio returns the share of reads that found a value, or zero if nothing was read yet.
+func (s Stats) HitRatio() float64 {
+	reads := s.Hits + s.Misses
+	if reads == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(reads)
+}
+
+// LatencyHistogram is a snapshot of the latency distribution of an operation.
+type LatencyHistogram struct {
+	Bounds []time.Duration // Upper bounds of the buckets, in increasing order.
+	Counts []uint64        // Number of observations per bucket; the last one counts those above every bound.
+	Count  uint64          // Total number of observations.
+	Sum    time.Duration   // Sum of all the observed latencies.
+}
+
+// statsRecorder maintains the statistics of a cache driver with atomic counters,
+// so that recording never contends with the cache lock.
+type statsRecorder struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	sets        atomic.Uint64
+	deletes     atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+	errors      atomic.Uint64
+	unavailable atomic.Uint64
+	retries     atomic.Uint64
+	items       atomic.Int64
+	latencies   map[string]*latencyRecorder // Never modified after creation, so safe for concurrent reads.
+}
+
+// latencyRecorder is the lock-free histogram of the latencies of a single operation.
+type latencyRecorder struct {
+	counts []atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Int64
+}
+
+// newStatsRecorder creates a statsRecorder with an empty histogram for every tracked operation.
+func newStatsRecorder() *statsRecorder {
+	s := &statsRecorder{latencies: make(map[string]*latencyRecorder, len(trackedOperations))}
+	for _, op := range trackedOperations {
+		s.latencies[op] = &latencyRecorder{counts: make([]atomic.Uint64, len(latencyBounds)+1)}
+	}
+	return s
+}
+
+// observe records the latency of an operation started at start.
+// It is meant to be deferred at the top of the instrumented methods.
+func (s *statsRecorder) observe(op string, start time.Time) {
+	l, ok := s.latencies[op]
+	if !ok {
+		return
+	}
+	elapsed := time.Since(start)
+	bucket := len(latencyBounds)
+	for i, bound := range latencyBounds {
+		if elapsed <= bound {
+			bucket = i
+			break
+		}
+	}
+	l.counts[bucket].Add(1)
+	l.count.Add(1)
+	l.sum.Add(int64(elapsed))
+}
+
+// read records a read that found a value if hit is true, or a miss otherwise.
+func (s *statsRecorder) read(hit bool) {
+	if hit {
+		s.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+	}
+}
+
+// failed counts err as an operation error if it is not nil, and returns it unchanged.
+func (s *statsRecorder) failed(err error) error {
+	if err != nil {
+		s.errors.Add(1)
+	}
+	return err
+}
+
+// lookup counts a read of a hash, list, set or sorted set as a hit if found is true and as a miss otherwise,
+// or as an operation error if err is not nil. It returns err unchanged.
+func (s *statsRecorder) lookup(found bool, err error) error {
+	if err != nil {
+		return s.failed(err)
+	}
+	s.read(found)
+	return nil
+}
+
+// wrote counts a write to a hash, list, set or sorted set, or an operation error if err is not nil.
+// It returns err unchanged.
+func (s *statsRecorder) wrote(err error) error {
+	if err != nil {
+		return s.failed(err)
+	}
+	s.sets.Add(1)
+	return nil
+}
+
+// removed counts the n fields, elements or members removed from a hash, list, set or sorted set,
+// or an operation error if err is not nil. It returns err unchanged.
+func (s *statsRecorder) removed(n int64, err error) error {
+	if err != nil {
+		return s.failed(err)
+	}
+	s.deletes.Add(uint64(n))
+	return nil
+}
+
+// snapshot returns the current statistics.
+func (s *statsRecorder) snapshot() Stats {
+	stats := Stats{
+		Hits:        s.hits.Load(),
+		Misses:      s.misses.Load(),
+		Sets:        s.sets.Load(),
+		Deletes:     s.deletes.Load(),
+		Evictions:   s.evictions.Load(),
+		Expirations: s.expirations.Load(),
+		Errors:      s.errors.Load(),
+		Unavailable: s.unavailable.Load(),
+		Retries:     s.retries.Load(),
+		Items:       s.items.Load(),
+		Latencies:   make(map[string]LatencyHistogram, len(s.latencies)),
+	}
+	for op, l := range s.latencies {
+		h := LatencyHistogram{
+			Bounds: append([]time.Duration(nil), latencyBounds...),
+			Counts: make([]uint64, len(l.counts)),
+			Count:  l.count.Load(),
+			Sum:    time.Duration(l.sum.Load()),
+		}
+		for i := range l.counts {
+			h.Counts[i] = l.counts[i].Load()
+		}
+		stats.Latencies[op] = h
+	}
+	return stats
+}
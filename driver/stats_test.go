@@ -0,0 +1,172 @@
+package driver_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sibeur/go-cache/cachetest"
+	"github.com/sibeur/go-cache/common"
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestMemoryCache_Stats(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	_ = cache.Set("key1", "value1")
+	_ = cache.SetWithExpire("key2", "value2", 10)
+	_, _ = cache.Get("key1")
+	_, _ = cache.Get("missing")
+	_ = cache.Delete("key2")
+	_ = cache.Delete("missing")
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, but got %d hits and %d misses", stats.Hits, stats.Misses)
+	}
+	if stats.Sets != 2 {
+		t.Errorf("Expected 2 sets, but got %d", stats.Sets)
+	}
+	if stats.Deletes != 1 {
+		t.Errorf("Expected 1 delete, but got %d", stats.Deletes)
+	}
+	if stats.Items != 1 {
+		t.Errorf("Expected 1 item, but got %d", stats.Items)
+	}
+	if stats.HitRatio() != 0.5 {
+		t.Errorf("Expected hit ratio 0.5, but got %v", stats.HitRatio())
+	}
+
+	// Every operation must be counted in its latency histogram
+	if count := stats.Latencies["get"].Count; count != 2 {
+		t.Errorf("Expected 2 get latencies, but got %d", count)
+	}
+	var total uint64
+	for _, count := range stats.Latencies["set"].Counts {
+		total += count
+	}
+	if total != 2 {
+		t.Errorf("Expected 2 set latencies in the buckets, but got %d", total)
+	}
+}
+
+func TestMemoryCache_Stats_Expirations(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Now())
+	cache := driver.NewMemoryCache(driver.WithClock(clock))
+
+	_ = cache.SetWithExpire("key1", "value1", 1)
+
+	// Let the value expire and the cleanup routine remove it
+	advanceCleanup(clock, 2*time.Second)
+
+	stats := cache.Stats()
+	if stats.Expirations != 1 {
+		t.Errorf("Expected 1 expiration, but got %d", stats.Expirations)
+	}
+	if stats.Items != 0 {
+		t.Errorf("Expected 0 items, but got %d", stats.Items)
+	}
+}
+
+func TestMemoryCache_Stats_Concurrent(t *testing.T) {
+	cache := driver.NewMemoryCache()
+
+	// Concurrent readers must all be counted
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, _ = cache.Get("missing")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if misses := cache.Stats().Misses; misses != 1000 {
+		t.Errorf("Expected 1000 misses, but got %d", misses)
+	}
+}
+
+func TestRedisCache_Stats(t *testing.T) {
+	// Create a Redis client for testing
+	client := newTestRedisClient(t)
+
+	// Create a RedisCache instance
+	cache := driver.NewRedisCache(client)
+
+	_ = cache.Set("key", "value")
+	_, _ = cache.Get("key")
+	_, _ = cache.Get("missing-key")
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, but got %d hits and %d misses", stats.Hits, stats.Misses)
+	}
+	if stats.Sets != 1 {
+		t.Errorf("Expected 1 set, but got %d", stats.Sets)
+	}
+	if stats.Items < 1 {
+		t.Errorf("Expected at least 1 item, but got %d", stats.Items)
+	}
+}
+
+func TestStats_DataStructures(t *testing.T) {
+	client := newTestRedisClient(t)
+	redisCache := driver.NewRedisCache(client)
+	_ = redisCache.Flush()
+	caches := map[string]interface {
+		HSet(key string, fields map[string]string) error
+		HGet(key string, field string) (string, error)
+		HDel(key string, fields ...string) error
+		RPush(key string, values ...string) (int64, error)
+		LPop(key string) (string, error)
+		SAdd(key string, members ...string) (int64, error)
+		SIsMember(key string, member string) (bool, error)
+		ZAdd(key string, member string, score float64) error
+		ZRange(key string, start int64, stop int64) ([]driver.ScoredMember, error)
+		ZRem(key string, members ...string) (int64, error)
+		Stats() driver.Stats
+	}{
+		"memory": driver.NewMemoryCache(),
+		"redis":  redisCache,
+	}
+	for name, cache := range caches {
+		t.Run(name, func(t *testing.T) {
+			// Four writes
+			_ = cache.HSet("hash", map[string]string{"field1": "value1", "field2": "value2"})
+			_, _ = cache.RPush("list", "a", "b")
+			_, _ = cache.SAdd("set", "a")
+			_ = cache.ZAdd("zset", "a", 1)
+
+			// Three hits and three misses
+			_, _ = cache.HGet("hash", "field1")
+			_, _ = cache.HGet("hash", "missing")
+			_, _ = cache.SIsMember("set", "a")
+			_, _ = cache.SIsMember("set", "missing")
+			_, _ = cache.ZRange("zset", 0, -1)
+			_, _ = cache.ZRange("missing", 0, -1)
+
+			// Four removals, the missing ones are not counted
+			_ = cache.HDel("hash", "field1", "field2", "missing")
+			_, _ = cache.LPop("list")
+			_, _ = cache.LPop("missing")
+			_, _ = cache.ZRem("zset", "a", "missing")
+
+			stats := cache.Stats()
+			if stats.Sets != 4 {
+				t.Errorf("Expected 4 sets, but got %d", stats.Sets)
+			}
+			if stats.Hits != 3 || stats.Misses != 3 {
+				t.Errorf("Expected 3 hits and 3 misses, but got %d hits and %d misses", stats.Hits, stats.Misses)
+			}
+			if stats.Deletes != 4 {
+				t.Errorf("Expected 4 deletes, but got %d", stats.Deletes)
+			}
+			if latency := stats.Latencies[common.OpGet]; latency.Count != 6 {
+				t.Errorf("Expected 6 timed reads, but got %d", latency.Count)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package driver_test
+
+import (
+	"testing"
+
+	"github.com/sibeur/go-cache/driver"
+)
+
+// taggedCache is the part of the drivers exercised by the cross-driver tag tests.
+type taggedCache interface {
+	Get(key string) (string, error)
+	Set(key string, value string) error
+	Delete(key string) error
+	SetWithTags(key string, value string, ttl uint64, tags ...string) error
+	InvalidateTags(tags ...string) (int64, error)
+	KeysByTag(tag string) ([]string, error)
+}
+
+// newTaggedCaches returns an empty cache of every driver supporting tags.
+func newTaggedCaches(t *testing.T) map[string]taggedCache {
+	redisCache := driver.NewRedisCache(newTestRedisClient(t))
+	_ = redisCache.Flush()
+	return map[string]taggedCache{
+		"memory": driver.NewMemoryCache(),
+		"redis":  redisCache,
+	}
+}
+
+func TestTagger_WritesReplaceTags(t *testing.T) {
+	for name, cache := range newTaggedCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			// Storing the key again with other tags removes it from the previous ones
+			_ = cache.SetWithTags("key1", "value1", 0, "tag1")
+			_ = cache.SetWithTags("key1", "value1", 0, "tag2")
+			if keys, _ := cache.KeysByTag("tag1"); len(keys) != 0 {
+				t.Errorf("Expected tag1 to be empty, but got %v", keys)
+			}
+			if removed, _ := cache.InvalidateTags("tag1"); removed != 0 {
+				t.Errorf("Expected no key to be removed by tag1, but got %d", removed)
+			}
+
+			// A plain write drops every tag
+			_ = cache.Set("key1", "value2")
+			if removed, _ := cache.InvalidateTags("tag2"); removed != 0 {
+				t.Errorf("Expected no key to be removed by tag2, but got %d", removed)
+			}
+			if value, _ := cache.Get("key1"); value != "value2" {
+				t.Errorf("Expected value2, but got %q", value)
+			}
+
+			// A deleted key stored again without tags is not invalidated by its former ones
+			_ = cache.SetWithTags("key2", "value1", 0, "tag3")
+			_ = cache.Delete("key2")
+			_ = cache.Set("key2", "value2")
+			if removed, _ := cache.InvalidateTags("tag3"); removed != 0 {
+				t.Errorf("Expected no key to be removed by tag3, but got %d", removed)
+			}
+			if value, _ := cache.Get("key2"); value != "value2" {
+				t.Errorf("Expected value2, but got %q", value)
+			}
+
+			// The current tags still invalidate the key
+			_ = cache.SetWithTags("key1", "value3", 0, "tag4", "tag5")
+			if removed, _ := cache.InvalidateTags("tag5"); removed != 1 {
+				t.Errorf("Expected 1 key to be removed by tag5, but got %d", removed)
+			}
+			if keys, _ := cache.KeysByTag("tag4"); len(keys) != 0 {
+				t.Errorf("Expected tag4 to be empty once key1 is invalidated, but got %v", keys)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Package metrics exports the activity of any cache.Cache as Prometheus metrics.
+//
+// Wrap a cache with New and use the returned Cache in place of the original one:
+//
+//	cache, err := metrics.New(c.NewCache(), "sessions", prometheus.DefaultRegisterer)
+//
+// Every metric carries a "cache" label with the given name and a "driver" label with the driver name,
+// so several caches can be registered on the same registry.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	redis "github.com/redis/go-redis/v9"
+	cache "github.com/sibeur/go-cache"
+	"github.com/sibeur/go-cache/common"
+	"github.com/sibeur/go-cache/driver"
+)
+
+// namespace prefixes the name of every metric.
+const namespace = "go_cache"
+
+// error types used as the value of the "type" label of the errors metric.
+const (
+	errorTypeTimeout     = "timeout"
+	errorTypeCanceled    = "canceled"
+	errorTypeNetwork     = "network"
+	errorTypeRedis       = "redis"
+	errorTypeUnavailable = "unavailable"
+	errorTypeOther       = "other"
+)
+
+// Cache is a cache.Cache recording Prometheus metrics about every operation of the cache it wraps.
+// Only the methods of the cache.Cache interface are exposed by the wrapper.
+type Cache struct {
+	cache.Cache
+
+	operations *prometheus.CounterVec   // Operations, by operation name.
+	hits       prometheus.Counter       // Reads that found a value.
+	misses     prometheus.Counter       // Reads that found no value.
+	errors     *prometheus.CounterVec   // Failed operations, by operation name and error type.
+	latency    *prometheus.HistogramVec // Operation latencies in seconds, by operation name.
+}
+
+// New wraps the cache and registers its collectors on the registerer, labelled with the cache name
+// and the driver name. It fails if a cache with the same name and driver is already registered.
+func New(c cache.Cache, name string, registerer prometheus.Registerer) (*Cache, error) {
+	labels := prometheus.Labels{"cache": name, "driver": c.GetDriverName()}
+	m := &Cache{
+		Cache: c,
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "operations_total",
+			Help:        "Number of cache operations.",
+			ConstLabels: labels,
+		}, []string{"operation"}),
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "hits_total",
+			Help:        "Number of cache reads that found a value.",
+			ConstLabels: labels,
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "misses_total",
+			Help:        "Number of cache reads that found no value.",
+			ConstLabels: labels,
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "errors_total",
+			Help:        "Number of cache operations that returned an error.",
+			ConstLabels: labels,
+		}, []string{"operation", "type"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "operation_duration_seconds",
+			Help:        "Latency of the cache operations.",
+			ConstLabels: labels,
+			Buckets:     []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		}, []string{"operation"}),
+	}
+	hitRatio := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "hit_ratio",
+		Help:        "Share of the cache reads that found a value.",
+		ConstLabels: labels,
+	}, m.hitRatio)
+	available := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "available",
+		Help:        "Whether the cache is available (1) or not (0).",
+		ConstLabels: labels,
+	}, func() float64 {
+		if c.IsCacheAvailable() {
+			return 1
+		}
+		return 0
+	})
+
+	collectors := []prometheus.Collector{m.operations, m.hits, m.misses, m.errors, m.latency, hitRatio, available}
+	for i, collector := range collectors {
+		if err := registerer.Register(collector); err != nil {
+			for _, registered := range collectors[:i] {
+				registerer.Unregister(registered)
+			}
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Get retrieves the value from the wrapped cache, counting a hit if a value was found and a miss otherwise.
+// A driver.ErrMiss error is counted as a miss, not as an error.
+func (m *Cache) Get(key string) (string, error) {
+	start := time.Now()
+	value, err := m.Cache.Get(key)
+	failure := err
+	switch {
+	case errors.Is(err, driver.ErrMiss), err == nil && value == "":
+		m.misses.Inc()
+		failure = nil
+	case err == nil:
+		m.hits.Inc()
+	}
+	m.observe(common.OpGet, start, failure)
+	return value, err
+}
+
+// Set sets the value in the wrapped cache and records the operation.
+func (m *Cache) Set(key string, value string) error {
+	start := time.Now()
+	err := m.Cache.Set(key, value)
+	m.observe(common.OpSet, start, err)
+	return err
+}
+
+// SetWithExpire sets the value with a TTL (in seconds) in the wrapped cache and records the operation.
+func (m *Cache) SetWithExpire(key string, value string, ttl uint64) error {
+	start := time.Now()
+	err := m.Cache.SetWithExpire(key, value, ttl)
+	m.observe(common.OpSet, start, err)
+	return err
+}
+
+// Delete deletes the value from the wrapped cache and records the operation.
+func (m *Cache) Delete(key string) error {
+	start := time.Now()
+	err := m.Cache.Delete(key)
+	m.observe(common.OpDelete, start, err)
+	return err
+}
+
+// Flush flushes the wrapped cache and records the operation.
+func (m *Cache) Flush() error {
+	start := time.Now()
+	err := m.Cache.Flush()
+	m.observe(common.OpFlush, start, err)
+	return err
+}
+
+// observe records an operation that started at start and returned err.
+func (m *Cache) observe(op string, start time.Time, err error) {
+	m.operations.WithLabelValues(op).Inc()
+	m.latency.WithLabelValues(op).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(op, errorType(err)).Inc()
+	}
+}
+
+// hitRatio returns the share of the reads that found a value, or zero if nothing was read yet.
+func (m *Cache) hitRatio() float64 {
+	hits, misses := counterValue(m.hits), counterValue(m.misses)
+	if hits+misses == 0 {
+		return 0
+	}
+	return hits / (hits + misses)
+}
+
+// counterValue returns the current value of the counter.
+func counterValue(counter prometheus.Counter) float64 {
+	metric := &dto.Metric{}
+	if err := counter.Write(metric); err != nil {
+		return 0
+	}
+	return metric.GetCounter().GetValue()
+}
+
+// errorType classifies err for the "type" label of the errors metric.
+func errorType(err error) string {
+	var netErr net.Error
+	var redisErr redis.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorTypeTimeout
+	case errors.Is(err, context.Canceled):
+		return errorTypeCanceled
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return errorTypeTimeout
+		}
+		return errorTypeNetwork
+	case errors.As(err, &redisErr):
+		return errorTypeRedis
+	case errors.Is(err, driver.ErrCacheUnavailable):
+		return errorTypeUnavailable
+	default:
+		return errorTypeOther
+	}
+}
@@ -0,0 +1,146 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sibeur/go-cache/driver"
+	"github.com/sibeur/go-cache/metrics"
+)
+
+// failingCache is a memory cache whose writes always fail with the given error.
+type failingCache struct {
+	*driver.MemoryCache
+	err error
+}
+
+func (f *failingCache) Set(key string, value string) error {
+	return f.err
+}
+
+func TestCache_Operations(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	cache, err := metrics.New(driver.NewMemoryCache(), "sessions", registry)
+	if err != nil {
+		t.Fatalf("Failed to create metrics cache: %v", err)
+	}
+
+	_ = cache.Set("key1", "value1")
+	_, _ = cache.Get("key1")
+	_, _ = cache.Get("missing")
+	_, _ = cache.Get("missing")
+	_ = cache.Delete("key1")
+
+	expected := `
+# HELP go_cache_operations_total Number of cache operations.
+# TYPE go_cache_operations_total counter
+go_cache_operations_total{cache="sessions",driver="memory",operation="delete"} 1
+go_cache_operations_total{cache="sessions",driver="memory",operation="get"} 3
+go_cache_operations_total{cache="sessions",driver="memory",operation="set"} 1
+# HELP go_cache_hits_total Number of cache reads that found a value.
+# TYPE go_cache_hits_total counter
+go_cache_hits_total{cache="sessions",driver="memory"} 1
+# HELP go_cache_misses_total Number of cache reads that found no value.
+# TYPE go_cache_misses_total counter
+go_cache_misses_total{cache="sessions",driver="memory"} 2
+`
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"go_cache_operations_total", "go_cache_hits_total", "go_cache_misses_total")
+	if err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+
+	// The hit ratio is derived from the hits and misses
+	ratio, err := gatherValue(registry, "go_cache_hit_ratio")
+	if err != nil {
+		t.Errorf("Failed to gather hit ratio: %v", err)
+	}
+	if ratio < 0.33 || ratio > 0.34 {
+		t.Errorf("Expected hit ratio 1/3, but got %v", ratio)
+	}
+
+	// Every operation is observed in the latency histogram
+	if count := testutil.CollectAndCount(registry, "go_cache_operation_duration_seconds"); count != 3 {
+		t.Errorf("Expected 3 latency histograms, but got %d", count)
+	}
+}
+
+func TestCache_Errors(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	failing := &failingCache{MemoryCache: driver.NewMemoryCache(), err: context.DeadlineExceeded}
+	cache, err := metrics.New(failing, "sessions", registry)
+	if err != nil {
+		t.Fatalf("Failed to create metrics cache: %v", err)
+	}
+
+	_ = cache.Set("key1", "value1")
+	failing.err = errors.New("boom")
+	_ = cache.Set("key1", "value1")
+	failing.err = driver.ErrCacheUnavailable
+	_ = cache.Set("key1", "value1")
+
+	expected := `
+# HELP go_cache_errors_total Number of cache operations that returned an error.
+# TYPE go_cache_errors_total counter
+go_cache_errors_total{cache="sessions",driver="memory",operation="set",type="other"} 1
+go_cache_errors_total{cache="sessions",driver="memory",operation="set",type="timeout"} 1
+go_cache_errors_total{cache="sessions",driver="memory",operation="set",type="unavailable"} 1
+`
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected), "go_cache_errors_total")
+	if err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
+
+func TestCache_Available(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	memory := driver.NewMemoryCache()
+	_, err := metrics.New(memory, "sessions", registry)
+	if err != nil {
+		t.Fatalf("Failed to create metrics cache: %v", err)
+	}
+
+	if value, _ := gatherValue(registry, "go_cache_available"); value != 1 {
+		t.Errorf("Expected cache to be available, but got %v", value)
+	}
+
+	memory.SetCacheAvailable(false)
+	if value, _ := gatherValue(registry, "go_cache_available"); value != 0 {
+		t.Errorf("Expected cache to be unavailable, but got %v", value)
+	}
+}
+
+func TestNew_DuplicateName(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if _, err := metrics.New(driver.NewMemoryCache(), "sessions", registry); err != nil {
+		t.Fatalf("Failed to create metrics cache: %v", err)
+	}
+
+	// A second cache with the same name and driver must be rejected
+	if _, err := metrics.New(driver.NewMemoryCache(), "sessions", registry); err == nil {
+		t.Errorf("Expected registration error, but got nil")
+	}
+
+	// A cache with another name can be registered on the same registry
+	if _, err := metrics.New(driver.NewMemoryCache(), "pages", registry); err != nil {
+		t.Errorf("Failed to create metrics cache: %v", err)
+	}
+}
+
+// gatherValue returns the value of the single gauge with the given name.
+func gatherValue(registry *prometheus.Registry, name string) (float64, error) {
+	families, err := registry.Gather()
+	if err != nil {
+		return 0, err
+	}
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetGauge().GetValue(), nil
+		}
+	}
+	return 0, errors.New("metric not found: " + name)
+}
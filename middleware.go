@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/sibeur/go-cache/common"
+)
+
+// Middleware wraps a Cache to add cross-cutting behavior such as logging, metrics or retries.
+// A middleware returns a Cache that usually delegates to the wrapped one.
+type Middleware func(Cache) Cache
+
+// Chain wraps the cache with the given middlewares. The first middleware is the outermost one,
+// so it sees every operation first and its result last. The optional interfaces the result implements, such as
+// Scanner or EventNotifier, are those the middlewares keep: WithHooks keeps them, see its documentation.
+func Chain(c Cache, middlewares ...Middleware) Cache {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		c = middlewares[i](c)
+	}
+	return c
+}
+
+// Operation describes a single cache operation passed to the Hooks.
+type Operation struct {
+	Name     string        // Name of the operation: "get", "set", "delete" or "flush".
+	Key      string        // Key of the operation, empty for flush. Before hooks may change it.
+	Value    string        // Value written by set, or value read by get once the operation is done.
+	TTL      uint64        // TTL in seconds of a set with expiration, zero otherwise.
+	Err      error         // Error returned by the operation, set once the operation is done.
+	Duration time.Duration // Time spent in the wrapped cache, set once the operation is done.
+}
+
+// Hooks are functions called around every Get, Set, SetWithExpire, Delete and Flush of a Cache.
+// Either hook may be nil.
+type Hooks struct {
+	// Before is called before the operation reaches the wrapped cache.
+	// It may change the key and the value of the operation.
+	Before func(op *Operation)
+
+	// After is called once the wrapped cache returned, with the result, error and duration of the operation.
+	After func(op *Operation)
+}
+
+// WithHooks returns a Middleware calling the hooks around every operation of the wrapped cache.
+//
+// The returned cache has the context variants of the operations (GetCtx, SetCtx and so on), so that WithContext
+// passes the context of the caller down to the wrapped cache through the hooks. It also implements the optional
+// interfaces of the wrapped cache, whose methods are forwarded without calling the hooks: every one of them if the
+// wrapped cache implements them all, as the memory and Redis drivers do, or StatsProvider and EventNotifier if it
+// implements both, as the disk driver does. A cache implementing another combination only keeps Cache.
+func WithHooks(hooks Hooks) Middleware {
+	return func(c Cache) Cache {
+		h := &hookedCache{Cache: c, hooks: hooks}
+		h.driver, _ = c.(contextDriver)
+		if d, ok := c.(fullDriver); ok {
+			return &hookedDriver{hookedCache: h, fullDriver: d}
+		}
+		if n, ok := c.(observableDriver); ok {
+			return &hookedObservable{hookedCache: h, observableDriver: n}
+		}
+		return h
+	}
+}
+
+// fullDriver is implemented by the caches having every optional interface, such as the memory and Redis drivers.
+type fullDriver interface {
+	Scanner
+	PatternDeleter
+	Tagger
+	HashCache
+	ListCache
+	SetCache
+	SortedSetCache
+	StatsProvider
+	EventNotifier
+}
+
+// observableDriver is implemented by the caches having statistics and events, such as the disk driver.
+type observableDriver interface {
+	StatsProvider
+	EventNotifier
+}
+
+// hookedCache is the Cache returned by the WithHooks middleware.
+type hookedCache struct {
+	Cache
+	driver contextDriver // The cache as a contextDriver, nil if it has no context variants.
+	hooks  Hooks
+}
+
+// hookedDriver is the hookedCache of a fullDriver, forwarding its optional interfaces.
+type hookedDriver struct {
+	*hookedCache
+	fullDriver
+}
+
+// hookedObservable is the hookedCache of an observableDriver, forwarding its statistics and events.
+type hookedObservable struct {
+	*hookedCache
+	observableDriver
+}
+
+// Get retrieves the value from the wrapped cache between the hooks.
+func (h *hookedCache) Get(key string) (string, error) {
+	return h.GetCtx(context.Background(), key)
+}
+
+// GetCtx is like Get, but passes the context to the wrapped cache.
+func (h *hookedCache) GetCtx(ctx context.Context, key string) (string, error) {
+	op := &Operation{Name: common.OpGet, Key: key}
+	h.run(ctx, op, func() error {
+		var err error
+		if h.driver != nil {
+			op.Value, err = h.driver.GetCtx(ctx, op.Key)
+		} else {
+			op.Value, err = h.Cache.Get(op.Key)
+		}
+		return err
+	})
+	return op.Value, op.Err
+}
+
+// Set sets the value in the wrapped cache between the hooks.
+func (h *hookedCache) Set(key string, value string) error {
+	return h.SetCtx(context.Background(), key, value)
+}
+
+// SetCtx is like Set, but passes the context to the wrapped cache.
+func (h *hookedCache) SetCtx(ctx context.Context, key string, value string) error {
+	op := &Operation{Name: common.OpSet, Key: key, Value: value}
+	h.run(ctx, op, func() error {
+		if h.driver != nil {
+			return h.driver.SetCtx(ctx, op.Key, op.Value)
+		}
+		return h.Cache.Set(op.Key, op.Value)
+	})
+	return op.Err
+}
+
+// SetWithExpire sets the value with a TTL (in seconds) in the wrapped cache between the hooks.
+func (h *hookedCache) SetWithExpire(key string, value string, ttl uint64) error {
+	return h.SetWithExpireCtx(context.Background(), key, value, ttl)
+}
+
+// SetWithExpireCtx is like SetWithExpire, but passes the context to the wrapped cache.
+func (h *hookedCache) SetWithExpireCtx(ctx context.Context, key string, value string, ttl uint64) error {
+	op := &Operation{Name: common.OpSet, Key: key, Value: value, TTL: ttl}
+	h.run(ctx, op, func() error {
+		if h.driver != nil {
+			return h.driver.SetWithExpireCtx(ctx, op.Key, op.Value, op.TTL)
+		}
+		return h.Cache.SetWithExpire(op.Key, op.Value, op.TTL)
+	})
+	return op.Err
+}
+
+// Delete deletes the value from the wrapped cache between the hooks.
+func (h *hookedCache) Delete(key string) error {
+	return h.DeleteCtx(context.Background(), key)
+}
+
+// DeleteCtx is like Delete, but passes the context to the wrapped cache.
+func (h *hookedCache) DeleteCtx(ctx context.Context, key string) error {
+	op := &Operation{Name: common.OpDelete, Key: key}
+	h.run(ctx, op, func() error {
+		if h.driver != nil {
+			return h.driver.DeleteCtx(ctx, op.Key)
+		}
+		return h.Cache.Delete(op.Key)
+	})
+	return op.Err
+}
+
+// Flush flushes the wrapped cache between the hooks.
+func (h *hookedCache) Flush() error {
+	return h.FlushCtx(context.Background())
+}
+
+// FlushCtx is like Flush, but passes the context to the wrapped cache.
+func (h *hookedCache) FlushCtx(ctx context.Context) error {
+	op := &Operation{Name: common.OpFlush}
+	h.run(ctx, op, func() error {
+		if h.driver != nil {
+			return h.driver.FlushCtx(ctx)
+		}
+		return h.Cache.Flush()
+	})
+	return op.Err
+}
+
+// run calls the Before hook, the operation and the After hook, recording the error and duration.
+// If the wrapped cache has no context variants, the operation fails with the context error once the context is done,
+// like WithContext does.
+func (h *hookedCache) run(ctx context.Context, op *Operation, call func() error) {
+	if h.hooks.Before != nil {
+		h.hooks.Before(op)
+	}
+	start := time.Now()
+	if h.driver == nil && ctx.Err() != nil {
+		op.Err = ctx.Err()
+	} else {
+		op.Err = call()
+	}
+	op.Duration = time.Since(start)
+	if h.hooks.After != nil {
+		h.hooks.After(op)
+	}
+}
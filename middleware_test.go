@@ -0,0 +1,144 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	redis "github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache"
+	"github.com/sibeur/go-cache/driver"
+)
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	trace := func(name string) cache.Middleware {
+		return cache.WithHooks(cache.Hooks{
+			Before: func(op *cache.Operation) { calls = append(calls, name+" before "+op.Name) },
+			After:  func(op *cache.Operation) { calls = append(calls, name+" after "+op.Name) },
+		})
+	}
+
+	c := cache.Chain(driver.NewMemoryCache(), trace("outer"), trace("inner"))
+	_ = c.Set("key", "value")
+
+	expected := "outer before set,inner before set,inner after set,outer after set"
+	if strings.Join(calls, ",") != expected {
+		t.Errorf("Expected calls %s, but got %s", expected, strings.Join(calls, ","))
+	}
+}
+
+func TestWithHooks_After(t *testing.T) {
+	var ops []cache.Operation
+	c := cache.Chain(driver.NewMemoryCache(), cache.WithHooks(cache.Hooks{
+		After: func(op *cache.Operation) { ops = append(ops, *op) },
+	}))
+
+	_ = c.SetWithExpire("key", "value", 10)
+	_, _ = c.Get("key")
+
+	if len(ops) != 2 {
+		t.Fatalf("Expected 2 operations, but got %d", len(ops))
+	}
+	if ops[0].Name != "set" || ops[0].Key != "key" || ops[0].TTL != 10 {
+		t.Errorf("Unexpected set operation: %+v", ops[0])
+	}
+	if ops[1].Name != "get" || ops[1].Value != "value" || ops[1].Err != nil {
+		t.Errorf("Unexpected get operation: %+v", ops[1])
+	}
+	if ops[1].Duration <= 0 {
+		t.Errorf("Expected a positive duration, but got %v", ops[1].Duration)
+	}
+}
+
+func TestWithHooks_Before_RewritesKey(t *testing.T) {
+	memory := driver.NewMemoryCache()
+	prefix := cache.WithHooks(cache.Hooks{
+		Before: func(op *cache.Operation) { op.Key = "tenant:42:" + op.Key },
+	})
+	c := cache.Chain(memory, prefix)
+
+	_ = c.Set("key", "value")
+
+	// The value must be stored under the prefixed key
+	if value, _ := memory.Get("tenant:42:key"); value != "value" {
+		t.Errorf("Expected value %s under the prefixed key, but got %s", "value", value)
+	}
+	if value, _ := c.Get("key"); value != "value" {
+		t.Errorf("Expected value %s, but got %s", "value", value)
+	}
+}
+
+func TestWithHooks_Error(t *testing.T) {
+	failure := errors.New("boom")
+	var seen error
+	c := cache.Chain(&erroringCache{Cache: driver.NewMemoryCache(), err: failure}, cache.WithHooks(cache.Hooks{
+		After: func(op *cache.Operation) { seen = op.Err },
+	}))
+
+	if err := c.Delete("key"); err != failure {
+		t.Errorf("Expected error %v, but got %v", failure, err)
+	}
+	if seen != failure {
+		t.Errorf("Expected hook to see error %v, but got %v", failure, seen)
+	}
+}
+
+// erroringCache is a cache whose deletions always fail.
+type erroringCache struct {
+	cache.Cache
+	err error
+}
+
+func (e *erroringCache) Delete(key string) error {
+	return e.err
+}
+
+func TestChain_KeepsOptionalInterfaces(t *testing.T) {
+	hooks := cache.WithHooks(cache.Hooks{})
+
+	// The memory driver keeps every optional interface through the chain
+	c := cache.Chain(driver.NewMemoryCache(), hooks, hooks)
+	if _, ok := c.(cache.Tagger); !ok {
+		t.Error("Expected the chain to implement Tagger")
+	}
+	if _, ok := c.(cache.Scanner); !ok {
+		t.Error("Expected the chain to implement Scanner")
+	}
+	_ = c.(cache.Tagger).SetWithTags("key", "value", 0, "tag1")
+	if n, _ := c.(cache.Scanner).Len(context.Background()); n != 1 {
+		t.Errorf("Expected 1 key, but got %d", n)
+	}
+
+	// The disk driver keeps its statistics and events only
+	c = cache.Chain(driver.NewDiskCache(t.TempDir()), hooks)
+	if _, ok := c.(cache.EventNotifier); !ok {
+		t.Error("Expected the chain to implement EventNotifier")
+	}
+	if _, ok := c.(cache.Tagger); ok {
+		t.Error("Expected the chain not to implement Tagger")
+	}
+}
+
+func TestChain_WithContext_Redis(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: testRedisAddr(t)})
+	t.Cleanup(func() { _ = client.Close() })
+	var ops []cache.Operation
+	c := cache.WithContext(cache.Chain(driver.NewRedisCache(client), cache.WithHooks(cache.Hooks{
+		After: func(op *cache.Operation) { ops = append(ops, *op) },
+	})))
+	hook := contextHook{contexts: make(chan context.Context, 10)}
+	client.AddHook(hook)
+
+	// The context of the caller goes through the hooks down to the Redis command
+	type requestKey struct{}
+	ctx := context.WithValue(context.Background(), requestKey{}, "request1")
+	_, _ = c.Get(ctx, "key")
+	if received := <-hook.contexts; received.Value(requestKey{}) != "request1" {
+		t.Error("Expected the command to be sent with the context of the caller")
+	}
+	if len(ops) != 1 || ops[0].Name != "get" {
+		t.Errorf("Expected the hooks to see the get, but got %+v", ops)
+	}
+}
@@ -0,0 +1,298 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sibeur/go-cache/common"
+	"github.com/sibeur/go-cache/driver"
+)
+
+// entryPrefix starts every value written by a ReadThrough, followed by the soft and hard expiration times
+// in Unix milliseconds, the duration of the load in microseconds and the value itself, separated by colons.
+// The load duration is kept in microseconds so that the loads faster than a millisecond still recompute early.
+const entryPrefix = "go-cache:rt2:"
+
+// defaultLoadTimeout bounds the loads of a ReadThrough unless WithLoadTimeout sets another limit.
+const defaultLoadTimeout = time.Minute
+
+// defaultRefreshBackoff is how long a ReadThrough waits after a failed refresh of a key before refreshing
+// it again, unless WithRefreshBackoff sets another delay.
+const defaultRefreshBackoff = 5 * time.Second
+
+// ErrLoadPanic is returned by ReadThrough.GetOrLoad, wrapped with the panic value, when the LoadFunc panicked.
+var ErrLoadPanic = errors.New(common.ErrLoadPanicMsg)
+
+// LoadFunc loads the value of a key from the source of truth, such as a database.
+type LoadFunc func(ctx context.Context, key string) (string, error)
+
+// ReadThrough serves values from a Cache and loads the missing ones with a LoadFunc.
+//
+// Every value it stores has a soft and a hard TTL. Until the soft TTL elapses, the value is fresh and served as is.
+// Between the soft and the hard TTL, the value is stale: it is still served at once while a background refresh
+// loads the new one (stale-while-revalidate). If the refresh fails, the stale value keeps being served until the
+// hard TTL (stale-if-error), without refreshing the key again before the refresh backoff elapses.
+// Past the hard TTL, the value is loaded before being returned.
+// Concurrent loads of the same key are merged into one within the process.
+//
+// With WithEarlyRecompute, a fresh value may also be refreshed in the background before its soft TTL elapses,
+// so that the instances sharing a cache do not all refresh it at the same moment.
+type ReadThrough struct {
+	cache       Cache                // The cache storing the loaded values.
+	softTTL     time.Duration        // How long a loaded value is fresh.
+	hardTTL     time.Duration        // How long a loaded value may be served at all.
+	clock       driver.Clock         // The clock telling whether a value is fresh, stale or expired.
+	loadTimeout time.Duration        // The limit of every load, zero for none.
+	beta        float64              // The eagerness of the early recomputations, zero to disable them.
+	backoff     time.Duration        // How long to wait after a failed load before refreshing the key again.
+	flightsM    sync.Mutex           // flightsM guards flights and failures.
+	flights     map[string]*flight   // The loads in progress, keyed by cache key.
+	failures    map[string]time.Time // When the keys whose last load failed may be refreshed again.
+}
+
+// flight is a load in progress, shared by every caller waiting for the same key.
+type flight struct {
+	done  chan struct{} // Closed once the load is over.
+	value string        // The loaded value, set before done is closed.
+	err   error         // The error of the load, set before done is closed.
+}
+
+// ReadThroughOption configures a ReadThrough.
+type ReadThroughOption func(*ReadThrough)
+
+// WithReadThroughClock sets the clock used to tell whether the values are fresh, stale or expired,
+// so that tests can move time forward instead of sleeping. By default, or if the clock is nil,
+// the system clock is used.
+func WithReadThroughClock(clock driver.Clock) ReadThroughOption {
+	return func(r *ReadThrough) {
+		if clock != nil {
+			r.clock = clock
+		}
+	}
+}
+
+// WithLoadTimeout limits the duration of every load, including the background refreshes. It defaults to
+// one minute; zero sets no limit.
+func WithLoadTimeout(timeout time.Duration) ReadThroughOption {
+	return func(r *ReadThrough) {
+		r.loadTimeout = timeout
+	}
+}
+
+// WithEarlyRecompute makes GetOrLoad refresh a fresh value in the background with a probability that grows
+// as its soft TTL nears, following the XFetch algorithm: the value is refreshed once
+//
+//	now - loadDuration * beta * ln(rand) >= softExpiry
+//
+// where loadDuration is how long the value took to load and rand is uniform in (0, 1]. The values that are slow
+// to load are thus refreshed earlier, and the refreshes of a key spread over the instances sharing the cache.
+// A beta of 1 is the usual choice, greater values refresh earlier; zero or less disables early refreshes.
+func WithEarlyRecompute(beta float64) ReadThroughOption {
+	return func(r *ReadThrough) {
+		r.beta = beta
+	}
+}
+
+// WithRefreshBackoff sets how long GetOrLoad keeps serving a stale value without refreshing it after
+// a refresh of its key failed, so that a failing source is not called again by every read. It defaults
+// to five seconds; zero refreshes again at once. The loads of missing or expired values are not delayed.
+func WithRefreshBackoff(backoff time.Duration) ReadThroughOption {
+	return func(r *ReadThrough) {
+		r.backoff = backoff
+	}
+}
+
+// NewReadThrough creates a ReadThrough storing the loaded values in c, fresh for softTTL and served
+// until hardTTL. A hard TTL shorter than the soft one is raised to it, so that values are never stale.
+// The values are stored with SetWithExpire, so every driver supports it, for at least a second, since
+// a TTL of zero would keep them forever.
+func NewReadThrough(c Cache, softTTL time.Duration, hardTTL time.Duration, opts ...ReadThroughOption) *ReadThrough {
+	r := &ReadThrough{
+		cache:       c,
+		softTTL:     softTTL,
+		hardTTL:     max(hardTTL, softTTL),
+		clock:       driver.SystemClock,
+		loadTimeout: defaultLoadTimeout,
+		backoff:     defaultRefreshBackoff,
+		flights:     make(map[string]*flight),
+		failures:    make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// GetOrLoad returns the value of the key, loading it with load when the cache holds no value or an expired one.
+// A stale value, or a fresh one picked for an early recomputation, is returned at once and refreshed
+// in the background, unless a load of the key failed within the refresh backoff. The loaded value is stored
+// in the cache; a failure to store it is ignored, so that the value is still returned. If the cache cannot be read,
+// the value is loaded as if it were missing.
+func (r *ReadThrough) GetOrLoad(ctx context.Context, key string, load LoadFunc) (string, error) {
+	raw, _ := r.cache.Get(key)
+	now := r.clock.Now()
+	if e, ok := decodeEntry(raw); ok && now.Before(e.hardExpiry) {
+		if !now.Before(e.softExpiry) || r.recomputeEarly(e, now) {
+			r.refresh(ctx, key, load, now)
+		}
+		return e.value, nil
+	}
+	f := r.start(ctx, key, load)
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// recomputeEarly reports whether the fresh entry is picked for an early recomputation at now.
+func (r *ReadThrough) recomputeEarly(e entry, now time.Time) bool {
+	if r.beta <= 0 || e.loadDuration <= 0 {
+		return false
+	}
+	gap := time.Duration(float64(e.loadDuration) * r.beta * -math.Log(1-rand.Float64()))
+	return !now.Add(gap).Before(e.softExpiry)
+}
+
+// refresh starts a background load of the key, unless its last load failed less than the refresh backoff
+// before now.
+func (r *ReadThrough) refresh(ctx context.Context, key string, load LoadFunc, now time.Time) {
+	r.flightsM.Lock()
+	defer r.flightsM.Unlock()
+	if retry, ok := r.failures[key]; ok && now.Before(retry) {
+		return
+	}
+	r.startLocked(ctx, key, load)
+}
+
+// start returns the load in progress for the key, starting one with load if there is none.
+// The load outlives the context of the caller, so that the other callers and the cache still get its value.
+func (r *ReadThrough) start(ctx context.Context, key string, load LoadFunc) *flight {
+	r.flightsM.Lock()
+	defer r.flightsM.Unlock()
+	return r.startLocked(ctx, key, load)
+}
+
+// startLocked is start for a caller holding flightsM.
+func (r *ReadThrough) startLocked(ctx context.Context, key string, load LoadFunc) *flight {
+	if f, ok := r.flights[key]; ok {
+		return f
+	}
+	f := &flight{done: make(chan struct{})}
+	r.flights[key] = f
+	go r.run(context.WithoutCancel(ctx), key, load, f)
+	return f
+}
+
+// run loads the key, stores the value on success and ends the flight. A panic of load ends the flight too,
+// with ErrLoadPanic, so that the callers waiting for it are never stuck. A failure is recorded until
+// the refresh backoff elapses.
+func (r *ReadThrough) run(ctx context.Context, key string, load LoadFunc, f *flight) {
+	defer func() {
+		if p := recover(); p != nil {
+			f.value, f.err = "", fmt.Errorf("%w: %v", ErrLoadPanic, p)
+		}
+		r.flightsM.Lock()
+		delete(r.flights, key)
+		r.recordLocked(key, f.err)
+		r.flightsM.Unlock()
+		close(f.done)
+	}()
+	if r.loadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.loadTimeout)
+		defer cancel()
+	}
+	start := r.clock.Now()
+	f.value, f.err = load(ctx, key)
+	if f.err == nil {
+		r.store(key, f.value, r.clock.Now().Sub(start))
+	}
+}
+
+// recordLocked records the outcome of a load of the key, for a caller holding flightsM. A failure delays
+// the next refresh of the key by the refresh backoff; the failures whose backoff elapsed are dropped,
+// so that the keys no longer read are not kept.
+func (r *ReadThrough) recordLocked(key string, err error) {
+	if err == nil || r.backoff <= 0 {
+		delete(r.failures, key)
+		return
+	}
+	now := r.clock.Now()
+	for k, retry := range r.failures {
+		if !now.Before(retry) {
+			delete(r.failures, k)
+		}
+	}
+	r.failures[key] = now.Add(r.backoff)
+}
+
+// store writes the value to the cache with its soft and hard expiration times and the duration of its load.
+// The TTL of the cache entry is the hard TTL, rounded up to the second, and at least a second.
+func (r *ReadThrough) store(key string, value string, loadDuration time.Duration) {
+	now := r.clock.Now()
+	e := entry{value: value, softExpiry: now.Add(r.softTTL), hardExpiry: now.Add(r.hardTTL), loadDuration: loadDuration}
+	ttl := max(uint64((r.hardTTL+time.Second-1)/time.Second), 1)
+	_ = r.cache.SetWithExpire(key, e.encode(), ttl)
+}
+
+// entry is a value stored by a ReadThrough together with its expiration times and the duration of its load.
+type entry struct {
+	value        string
+	softExpiry   time.Time
+	hardExpiry   time.Time
+	loadDuration time.Duration
+}
+
+// encode returns the representation of the entry stored in the cache.
+func (e entry) encode() string {
+	var b strings.Builder
+	b.WriteString(entryPrefix)
+	b.WriteString(strconv.FormatInt(e.softExpiry.UnixMilli(), 10))
+	b.WriteByte(':')
+	b.WriteString(strconv.FormatInt(e.hardExpiry.UnixMilli(), 10))
+	b.WriteByte(':')
+	b.WriteString(strconv.FormatInt(e.loadDuration.Microseconds(), 10))
+	b.WriteByte(':')
+	b.WriteString(e.value)
+	return b.String()
+}
+
+// decodeEntry parses a value stored by a ReadThrough. It returns false for anything else, including
+// a missing value or one written directly to the cache, which are then loaded again.
+func decodeEntry(raw string) (entry, bool) {
+	rest, ok := strings.CutPrefix(raw, entryPrefix)
+	if !ok {
+		return entry{}, false
+	}
+	fields := strings.SplitN(rest, ":", 4)
+	if len(fields) != 4 {
+		return entry{}, false
+	}
+	soft, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return entry{}, false
+	}
+	hard, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return entry{}, false
+	}
+	loadUs, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return entry{}, false
+	}
+	return entry{
+		value:        fields[3],
+		softExpiry:   time.UnixMilli(soft),
+		hardExpiry:   time.UnixMilli(hard),
+		loadDuration: time.Duration(loadUs) * time.Microsecond,
+	}, true
+}
@@ -0,0 +1,305 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sibeur/go-cache"
+	"github.com/sibeur/go-cache/cachetest"
+	"github.com/sibeur/go-cache/driver"
+)
+
+// newReadThroughs returns a ReadThrough over every driver, fresh for a minute and served for an hour,
+// together with the fake clock telling them the time.
+func newReadThroughs(t *testing.T) (map[string]*cache.ReadThrough, *cachetest.FakeClock) {
+	clock := cachetest.NewFakeClock(time.Now())
+	client := redis.NewClient(&redis.Options{Addr: testRedisAddr(t)})
+	t.Cleanup(func() { _ = client.Close() })
+	caches := map[string]cache.Cache{
+		"memory": driver.NewMemoryCache(driver.WithClock(clock)),
+		"redis":  driver.NewRedisCache(client),
+		"disk":   driver.NewDiskCache(t.TempDir()),
+	}
+	readThroughs := make(map[string]*cache.ReadThrough, len(caches))
+	for name, c := range caches {
+		_ = c.Flush()
+		readThroughs[name] = cache.NewReadThrough(c, time.Minute, time.Hour, cache.WithReadThroughClock(clock))
+	}
+	return readThroughs, clock
+}
+
+// countingLoader is a LoadFunc returning its value or error and counting its calls.
+type countingLoader struct {
+	mutex sync.Mutex
+	value string
+	err   error
+	calls atomic.Int32
+}
+
+func (l *countingLoader) load(ctx context.Context, key string) (string, error) {
+	l.calls.Add(1)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.value, l.err
+}
+
+func (l *countingLoader) set(value string, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.value, l.err = value, err
+}
+
+// eventually fails the test unless cond becomes true within a second.
+func eventually(t *testing.T, cond func() bool, msg string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReadThrough_Load(t *testing.T) {
+	readThroughs, _ := newReadThroughs(t)
+	for name, rt := range readThroughs {
+		t.Run(name, func(t *testing.T) {
+			loader := &countingLoader{value: "value1"}
+
+			// The first read loads the value, the next ones are served from the cache
+			for i := 0; i < 3; i++ {
+				value, err := rt.GetOrLoad(context.Background(), "key1", loader.load)
+				if value != "value1" || err != nil {
+					t.Fatalf("Expected value1 and no error, but got %q and %v", value, err)
+				}
+			}
+			if calls := loader.calls.Load(); calls != 1 {
+				t.Errorf("Expected 1 load, but got %d", calls)
+			}
+		})
+	}
+}
+
+func TestReadThrough_StaleWhileRevalidate(t *testing.T) {
+	readThroughs, clock := newReadThroughs(t)
+	loader := &countingLoader{value: "value1"}
+	for _, rt := range readThroughs {
+		_, _ = rt.GetOrLoad(context.Background(), "key1", loader.load)
+	}
+	loader.set("value2", nil)
+	clock.Advance(2 * time.Minute)
+
+	for name, rt := range readThroughs {
+		t.Run(name, func(t *testing.T) {
+			// The stale value is served at once, then replaced by the background refresh
+			if value, err := rt.GetOrLoad(context.Background(), "key1", loader.load); value != "value1" || err != nil {
+				t.Fatalf("Expected the stale value1 and no error, but got %q and %v", value, err)
+			}
+			eventually(t, func() bool {
+				value, _ := rt.GetOrLoad(context.Background(), "key1", loader.load)
+				return value == "value2"
+			}, "Expected the value to be refreshed in the background")
+		})
+	}
+}
+
+func TestReadThrough_StaleIfError(t *testing.T) {
+	readThroughs, clock := newReadThroughs(t)
+	loader := &countingLoader{value: "value1"}
+	for _, rt := range readThroughs {
+		_, _ = rt.GetOrLoad(context.Background(), "key1", loader.load)
+	}
+	errLoad := errors.New("database is down")
+	loader.set("", errLoad)
+	clock.Advance(2 * time.Minute)
+
+	for name, rt := range readThroughs {
+		t.Run(name, func(t *testing.T) {
+			// The stale value keeps being served while the refreshes fail
+			calls := loader.calls.Load()
+			for i := 0; i < 3; i++ {
+				if value, err := rt.GetOrLoad(context.Background(), "key1", loader.load); value != "value1" || err != nil {
+					t.Fatalf("Expected the stale value1 and no error, but got %q and %v", value, err)
+				}
+			}
+			eventually(t, func() bool { return loader.calls.Load() > calls }, "Expected a background refresh")
+		})
+	}
+
+	// Past the hard TTL, the error of the load is returned
+	clock.Advance(time.Hour)
+	for name, rt := range readThroughs {
+		t.Run(name+"/expired", func(t *testing.T) {
+			if _, err := rt.GetOrLoad(context.Background(), "key1", loader.load); !errors.Is(err, errLoad) {
+				t.Errorf("Expected the load error, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestReadThrough_RefreshBackoff(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Now())
+	rt := cache.NewReadThrough(driver.NewMemoryCache(driver.WithClock(clock)), time.Minute, time.Hour,
+		cache.WithReadThroughClock(clock), cache.WithRefreshBackoff(10*time.Second))
+	loader := &countingLoader{value: "value1"}
+	_, _ = rt.GetOrLoad(context.Background(), "key1", loader.load)
+	loader.set("", errors.New("database is down"))
+	clock.Advance(2 * time.Minute)
+
+	// After a failed refresh, the stale value is served without calling the source again
+	_, _ = rt.GetOrLoad(context.Background(), "key1", loader.load)
+	eventually(t, func() bool { return loader.calls.Load() == 2 }, "Expected a background refresh")
+	for i := 0; i < 10; i++ {
+		if value, err := rt.GetOrLoad(context.Background(), "key1", loader.load); value != "value1" || err != nil {
+			t.Fatalf("Expected the stale value1 and no error, but got %q and %v", value, err)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if calls := loader.calls.Load(); calls != 2 {
+		t.Errorf("Expected no refresh within the backoff, but got %d loads", calls)
+	}
+
+	// Once the backoff elapses, the key is refreshed again
+	loader.set("value2", nil)
+	clock.Advance(10 * time.Second)
+	eventually(t, func() bool {
+		value, _ := rt.GetOrLoad(context.Background(), "key1", loader.load)
+		return value == "value2"
+	}, "Expected the value to be refreshed once the backoff elapsed")
+}
+
+func TestReadThrough_MergesConcurrentLoads(t *testing.T) {
+	rt := cache.NewReadThrough(driver.NewMemoryCache(), time.Minute, time.Hour)
+	release := make(chan struct{})
+	var calls atomic.Int32
+	load := func(ctx context.Context, key string) (string, error) {
+		calls.Add(1)
+		<-release
+		return "value1", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if value, err := rt.GetOrLoad(context.Background(), "key1", load); value != "value1" || err != nil {
+				t.Errorf("Expected value1 and no error, but got %q and %v", value, err)
+			}
+		}()
+	}
+	eventually(t, func() bool { return calls.Load() == 1 }, "Expected the load to start")
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls := calls.Load(); calls != 1 {
+		t.Errorf("Expected 1 load, but got %d", calls)
+	}
+}
+
+func TestReadThrough_ContextCanceled(t *testing.T) {
+	rt := cache.NewReadThrough(driver.NewMemoryCache(), time.Minute, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+	load := func(ctx context.Context, key string) (string, error) {
+		cancel()
+		<-release
+		return "value1", nil
+	}
+
+	// The caller stops waiting, while the load goes on for the others
+	if _, err := rt.GetOrLoad(ctx, "key1", load); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, but got %v", context.Canceled, err)
+	}
+}
+
+func TestReadThrough_EarlyRecompute(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Now())
+	var calls atomic.Int32
+	load := func(ctx context.Context, key string) (string, error) {
+		n := calls.Add(1)
+		clock.Advance(time.Second) // the load takes a second
+		return "value" + strconv.Itoa(int(n)), nil
+	}
+
+	// Far from the soft TTL, a value is almost never refreshed early
+	rt := cache.NewReadThrough(driver.NewMemoryCache(driver.WithClock(clock)), time.Minute, time.Hour,
+		cache.WithReadThroughClock(clock), cache.WithEarlyRecompute(1))
+	for i := 0; i < 100; i++ {
+		if value, err := rt.GetOrLoad(context.Background(), "key1", load); value != "value1" || err != nil {
+			t.Fatalf("Expected value1 and no error, but got %q and %v", value, err)
+		}
+	}
+	if calls := calls.Load(); calls != 1 {
+		t.Errorf("Expected no early refresh, but got %d loads", calls)
+	}
+
+	// An eager beta refreshes the fresh value in the background, while still serving it
+	rt = cache.NewReadThrough(driver.NewMemoryCache(driver.WithClock(clock)), time.Minute, time.Hour,
+		cache.WithReadThroughClock(clock), cache.WithEarlyRecompute(1e6))
+	calls.Store(0)
+	_, _ = rt.GetOrLoad(context.Background(), "key1", load)
+	if value, err := rt.GetOrLoad(context.Background(), "key1", load); value != "value1" || err != nil {
+		t.Fatalf("Expected the fresh value1 and no error, but got %q and %v", value, err)
+	}
+	eventually(t, func() bool { return calls.Load() == 2 }, "Expected an early refresh")
+}
+
+func TestReadThrough_EarlyRecompute_SubMillisecondLoad(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Now())
+	var calls atomic.Int32
+	load := func(ctx context.Context, key string) (string, error) {
+		n := calls.Add(1)
+		clock.Advance(100 * time.Microsecond) // the load takes less than a millisecond
+		return "value" + strconv.Itoa(int(n)), nil
+	}
+
+	// A load faster than a millisecond still counts, so that the value is refreshed early
+	rt := cache.NewReadThrough(driver.NewMemoryCache(driver.WithClock(clock)), time.Minute, time.Hour,
+		cache.WithReadThroughClock(clock), cache.WithEarlyRecompute(1e7))
+	_, _ = rt.GetOrLoad(context.Background(), "key1", load)
+	if value, err := rt.GetOrLoad(context.Background(), "key1", load); value != "value1" || err != nil {
+		t.Fatalf("Expected the fresh value1 and no error, but got %q and %v", value, err)
+	}
+	eventually(t, func() bool { return calls.Load() == 2 }, "Expected an early refresh")
+}
+
+func TestReadThrough_LoadPanics(t *testing.T) {
+	rt := cache.NewReadThrough(driver.NewMemoryCache(), time.Minute, time.Hour)
+	load := func(ctx context.Context, key string) (string, error) {
+		panic("database driver bug")
+	}
+
+	// The panic is returned to the caller instead of crashing the process, and the next load runs again
+	for i := 0; i < 2; i++ {
+		if value, err := rt.GetOrLoad(context.Background(), "key1", load); value != "" || !errors.Is(err, cache.ErrLoadPanic) {
+			t.Errorf("Expected an empty value and ErrLoadPanic, but got %q and %v", value, err)
+		}
+	}
+	loader := &countingLoader{value: "value1"}
+	if value, err := rt.GetOrLoad(context.Background(), "key1", loader.load); value != "value1" || err != nil {
+		t.Errorf("Expected value1 and no error, but got %q and %v", value, err)
+	}
+}
+
+func TestReadThrough_ZeroTTL(t *testing.T) {
+	clock := cachetest.NewFakeClock(time.Now())
+	c := driver.NewMemoryCache(driver.WithClock(clock))
+	rt := cache.NewReadThrough(c, 0, 0, cache.WithReadThroughClock(clock))
+	loader := &countingLoader{value: "value1"}
+	_, _ = rt.GetOrLoad(context.Background(), "key1", loader.load)
+
+	// A zero TTL must not keep the entry forever
+	clock.Advance(2 * time.Second)
+	if value, err := c.Get("key1"); value != "" || !errors.Is(err, driver.ErrMiss) {
+		t.Errorf("Expected the entry to expire, but got %q and %v", value, err)
+	}
+}
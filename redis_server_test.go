@@ -0,0 +1,15 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/sibeur/go-cache/cachetest"
+)
+
+// testRedisAddr returns the address of the Redis server the test runs against.
+// The fake server is stopped when the test ends.
+func testRedisAddr(t *testing.T) string {
+	t.Helper()
+	addr, _ := cachetest.RedisServer(t)
+	return addr
+}
@@ -0,0 +1,165 @@
+// Package tracing records an OpenTelemetry span for every operation of a cache.ContextCache.
+//
+// Wrap a cache with New and pass the request context to every operation:
+//
+//	traced := tracing.New(c.WithContext(c.NewCache()))
+//	value, err := traced.Get(ctx, "key")
+//
+// Each span carries the driver name, the key (optionally hashed) and, for reads, whether the key was found.
+package tracing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	cache "github.com/sibeur/go-cache"
+	"github.com/sibeur/go-cache/common"
+	"github.com/sibeur/go-cache/driver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the tracer of this package.
+const instrumentationName = "github.com/sibeur/go-cache/tracing"
+
+// Attribute keys set on the cache spans.
+const (
+	DriverKey = attribute.Key("cache.driver")
+	KeyKey    = attribute.Key("cache.key")
+	HitKey    = attribute.Key("cache.hit")
+	TTLKey    = attribute.Key("cache.ttl")
+)
+
+// Option configures the tracing decorator.
+type Option func(*Cache)
+
+// WithTracerProvider sets the provider of the tracer creating the spans.
+// By default the global provider registered with otel.SetTracerProvider is used.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *Cache) {
+		c.tracer = provider.Tracer(instrumentationName)
+	}
+}
+
+// WithHashedKeys records a SHA-256 hash of the keys instead of their value,
+// for keys that contain personal or secret data.
+func WithHashedKeys() Option {
+	return func(c *Cache) {
+		c.hashKeys = true
+	}
+}
+
+// Cache is a cache.ContextCache recording a span for every operation of the cache it wraps.
+type Cache struct {
+	cache    cache.ContextCache // The traced cache.
+	tracer   trace.Tracer       // The tracer creating the spans.
+	hashKeys bool               // Whether keys are hashed before being recorded.
+}
+
+// New wraps the cache with the tracing decorator configured by the given options.
+func New(c cache.ContextCache, opts ...Option) *Cache {
+	t := &Cache{
+		cache:  c,
+		tracer: otel.GetTracerProvider().Tracer(instrumentationName),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Get retrieves the value from the wrapped cache in a "cache get" span, recording whether it was found.
+// A driver.ErrMiss error is recorded as a miss, not as an error.
+func (t *Cache) Get(ctx context.Context, key string) (string, error) {
+	ctx, span := t.start(ctx, common.OpGet, key)
+	defer span.End()
+
+	value, err := t.cache.Get(ctx, key)
+	hit := err == nil && value != ""
+	span.SetAttributes(HitKey.Bool(hit))
+	if !errors.Is(err, driver.ErrMiss) {
+		recordError(span, err)
+	}
+	return value, err
+}
+
+// Set sets the value in the wrapped cache in a "cache set" span.
+func (t *Cache) Set(ctx context.Context, key string, value string) error {
+	ctx, span := t.start(ctx, common.OpSet, key)
+	defer span.End()
+
+	err := t.cache.Set(ctx, key, value)
+	recordError(span, err)
+	return err
+}
+
+// SetWithExpire sets the value with a TTL (in seconds) in the wrapped cache in a "cache set" span.
+func (t *Cache) SetWithExpire(ctx context.Context, key string, value string, ttl uint64) error {
+	ctx, span := t.start(ctx, common.OpSet, key, TTLKey.Int64(int64(ttl)))
+	defer span.End()
+
+	err := t.cache.SetWithExpire(ctx, key, value, ttl)
+	recordError(span, err)
+	return err
+}
+
+// Delete deletes the value from the wrapped cache in a "cache delete" span.
+func (t *Cache) Delete(ctx context.Context, key string) error {
+	ctx, span := t.start(ctx, common.OpDelete, key)
+	defer span.End()
+
+	err := t.cache.Delete(ctx, key)
+	recordError(span, err)
+	return err
+}
+
+// Flush flushes the wrapped cache in a "cache flush" span.
+func (t *Cache) Flush(ctx context.Context) error {
+	ctx, span := t.start(ctx, common.OpFlush, "")
+	defer span.End()
+
+	err := t.cache.Flush(ctx)
+	recordError(span, err)
+	return err
+}
+
+// IsCacheAvailable checks if the wrapped cache is available for use.
+func (t *Cache) IsCacheAvailable() bool {
+	return t.cache.IsCacheAvailable()
+}
+
+// GetDriverName returns the name of the driver of the wrapped cache.
+func (t *Cache) GetDriverName() string {
+	return t.cache.GetDriverName()
+}
+
+// start opens the client span of an operation on the given key. An empty key is not recorded.
+func (t *Cache) start(ctx context.Context, op string, key string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, DriverKey.String(t.cache.GetDriverName()))
+	if key != "" {
+		attrs = append(attrs, KeyKey.String(t.recordedKey(key)))
+	}
+	return t.tracer.Start(ctx, "cache "+op, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// recordedKey returns the key as it must appear on the span.
+func (t *Cache) recordedKey(key string) string {
+	if !t.hashKeys {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordError marks the span as failed if err is not nil.
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,105 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	cache "github.com/sibeur/go-cache"
+	"github.com/sibeur/go-cache/driver"
+	"github.com/sibeur/go-cache/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTracedCache returns a traced memory cache and the recorder receiving its spans.
+func newTracedCache(opts ...tracing.Option) (*tracing.Cache, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	opts = append(opts, tracing.WithTracerProvider(provider))
+	return tracing.New(cache.WithContext(driver.NewMemoryCache()), opts...), recorder
+}
+
+// attributes returns the attributes of the span as a map.
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestCache_Spans(t *testing.T) {
+	traced, recorder := newTracedCache()
+	ctx := context.Background()
+
+	_ = traced.Set(ctx, "key1", "value1")
+	_, _ = traced.Get(ctx, "key1")
+	_, _ = traced.Get(ctx, "missing")
+	_ = traced.Delete(ctx, "key1")
+	_ = traced.Flush(ctx)
+
+	spans := recorder.Ended()
+	expectedNames := []string{"cache set", "cache get", "cache get", "cache delete", "cache flush"}
+	if len(spans) != len(expectedNames) {
+		t.Fatalf("Expected %d spans, but got %d", len(expectedNames), len(spans))
+	}
+	for i, name := range expectedNames {
+		if spans[i].Name() != name {
+			t.Errorf("Expected span %d to be named %s, but got %s", i, name, spans[i].Name())
+		}
+		if driverName := attributes(spans[i])[tracing.DriverKey].AsString(); driverName != "memory" {
+			t.Errorf("Expected driver attribute memory, but got %s", driverName)
+		}
+	}
+
+	// Reads record the key and whether it was found
+	hit, miss := attributes(spans[1]), attributes(spans[2])
+	if hit[tracing.KeyKey].AsString() != "key1" || !hit[tracing.HitKey].AsBool() {
+		t.Errorf("Expected hit on key1, but got %v", hit)
+	}
+	if miss[tracing.KeyKey].AsString() != "missing" || miss[tracing.HitKey].AsBool() {
+		t.Errorf("Expected miss on missing, but got %v", miss)
+	}
+}
+
+func TestCache_HashedKeys(t *testing.T) {
+	traced, recorder := newTracedCache(tracing.WithHashedKeys())
+
+	_ = traced.Set(context.Background(), "user:alice@example.com", "value1")
+
+	key := attributes(recorder.Ended()[0])[tracing.KeyKey].AsString()
+	if key == "user:alice@example.com" || len(key) != 64 {
+		t.Errorf("Expected hashed key, but got %s", key)
+	}
+}
+
+func TestCache_ParentSpan(t *testing.T) {
+	traced, recorder := newTracedCache()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	// The cache span must be a child of the span carried by the context
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	_, _ = traced.Get(ctx, "key1")
+	parent.End()
+
+	spans := recorder.Ended()
+	if spans[0].Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("Expected cache span to be a child of the request span")
+	}
+}
+
+func TestCache_CanceledContext(t *testing.T) {
+	traced, recorder := newTracedCache()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := traced.Set(ctx, "key1", "value1")
+	if err != context.Canceled {
+		t.Errorf("Expected error %v, but got %v", context.Canceled, err)
+	}
+	if status := recorder.Ended()[0].Status(); status.Code != codes.Error {
+		t.Errorf("Expected span status error, but got %v", status.Code)
+	}
+}